@@ -2,7 +2,10 @@ module github.com/thiagozs/go-mbsdk
 
 go 1.17
 
-require github.com/hashicorp/go-retryablehttp v0.7.0
+require (
+	github.com/gorilla/websocket v1.5.0
+	github.com/hashicorp/go-retryablehttp v0.7.0
+)
 
 require (
 	github.com/hashicorp/go-cleanhttp v0.5.1 // indirect
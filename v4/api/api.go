@@ -1,10 +1,12 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -13,13 +15,19 @@ import (
 	"github.com/thiagozs/go-cache/v1/cache/options"
 	"github.com/thiagozs/go-mbsdk/v4/config"
 	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/paper"
 	"github.com/thiagozs/go-mbsdk/v4/pkg/cache"
 	"github.com/thiagozs/go-mbsdk/v4/pkg/caller"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/ratelimit"
 	"github.com/thiagozs/go-mbsdk/v4/pkg/replacer"
 )
 
+// zerologOnce guards the zerolog package settings, which are global and would
+// race when several Api instances are created concurrently.
+var zerologOnce sync.Once
+
 func New(opts ...Options) (*Api, error) {
-	mts := &ApiCfg{}
+	mts := &ApiCfg{placeRetries: 2, placeRetryWait: time.Second, symbolsTTL: time.Hour}
 	for _, op := range opts {
 		err := op(mts)
 		if err != nil {
@@ -27,11 +35,12 @@ func New(opts ...Options) (*Api, error) {
 		}
 	}
 
-	zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	zerologOnce.Do(func() {
+		zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+		zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	})
 
-	log := zerolog.New(os.Stderr).With().
+	log := zerolog.New(os.Stderr).Level(zerolog.InfoLevel).With().
 		Caller().
 		Timestamp().Logger()
 
@@ -45,65 +54,135 @@ func New(opts ...Options) (*Api, error) {
 		mts.cache = cache
 	}
 
-	config.Config.Login = mts.key
-	config.Config.Password = mts.secret
-	config.Config.Cache = mts.cache
-	config.Config.Debug = mts.debug
-	config.Config.Endpoint = mts.endpoint
+	if mts.limiter == nil && !mts.disableLimiter {
+		mts.limiter = ratelimit.New(ratelimit.DefaultLimits())
+	}
 
-	return &Api{mts.cache, log}, nil
-}
+	callerOpts := []caller.Options{}
+	if mts.transport != nil {
+		hc := &http.Client{}
+		if mts.httpClient != nil {
+			*hc = *mts.httpClient
+		}
+		hc.Transport = mts.transport
+		mts.httpClient = hc
+	}
+	if mts.httpClient != nil {
+		callerOpts = append(callerOpts, caller.OptHTTPClient(mts.httpClient))
+	}
+	if mts.retryPolicy != nil {
+		callerOpts = append(callerOpts, caller.OptRetryPolicy(*mts.retryPolicy))
+	}
 
-func (a *Api) AuthorizationToken() (models.AuthoritionToken, error) {
-	auth := models.AuthoritionToken{}
-	c, err := caller.ClientWithForm(http.MethodPost, a.cache)
+	rest, err := caller.NewClient(callerOpts...)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithForm")
+		return &Api{}, err
+	}
+
+	cfg := &config.Configure{
+		Login:    mts.key,
+		Password: mts.secret,
+		Cache:    mts.cache,
+		Debug:    mts.debug,
+		Endpoint: mts.endpoint,
+	}
+
+	a := &Api{
+		cfg:            cfg,
+		cache:          mts.cache,
+		log:            log,
+		account:        mts.account,
+		validateOrders: mts.validateOrders,
+		autoRound:      mts.autoRound,
+		ruleOverrides:  make(map[string]OrderRules),
+		symbolsTTL:     mts.symbolsTTL,
+		limiter:        mts.limiter,
+		rest:           rest,
+		placeRetries:   mts.placeRetries,
+		placeRetryWait: mts.placeRetryWait,
+		wdMax:          mts.withdrawMax,
+		wdDaily:        mts.withdrawDaily,
+		wdSent:         make(map[string][]withdrawRecord),
+	}
+	a.limit(a.rest)
+
+	if mts.paperTrading {
+		market := paper.MarketDataFunc(func(ctx context.Context, symbol string) (models.OrderBookResponse, error) {
+			return a.OrderBookWithContext(ctx, symbol, "")
+		})
+		account, err := paper.New(append([]paper.Options{paper.OptMarketData(market)}, mts.paperOpts...)...)
+		if err != nil {
+			return &Api{}, err
 		}
-		return auth, err
+		a.paper = account
 	}
 
+	for _, rules := range mts.orderRules {
+		a.SetOrderRules(rules)
+	}
+
+	if mts.withdrawList {
+		a.wdBook = make(map[string]WithdrawAddress)
+		for _, addr := range mts.withdrawBook {
+			a.AddWithdrawAddress(addr)
+		}
+	}
+
+	return a, nil
+}
+
+func (a *Api) AuthorizationToken() (models.AuthoritionToken, error) {
+	return a.AuthorizationTokenWithContext(context.Background())
+}
+
+func (a *Api) AuthorizationTokenWithContext(ctx context.Context) (models.AuthoritionToken, error) {
+	auth := models.AuthoritionToken{}
 	endpoint, err := replacer.Endpoint(replacer.OptKey("AUTHORIZE"),
+		replacer.OptConfig(a.cfg),
 		replacer.OptCache(a.cache),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("EndPoint")
 		}
 		return auth, err
 	}
 
-	res, err := c.PostFormWithResponse(endpoint)
-	defer func() {
-		if err := res.Body.Close(); err != nil {
-			return
-		}
-	}()
+	req := caller.RequestWithForm(http.MethodPost, endpoint, a.cfg)
+	res, err := a.rest.Do(ratelimit.WithKey(ctx, "AUTHORIZE"), req)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("PostFormWithResponse")
 		}
 		return auth, err
 	}
+	defer res.Body.Close()
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return auth, err
 	}
 
+	if res.StatusCode >= 400 {
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
+		}
+		return auth, err
+	}
+
 	if err := json.Unmarshal(bts, &auth); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal AuthoritionToken")
 		}
 		return auth, err
 	}
 
 	if err := a.CacheSetAuthorize(auth); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("SetAuthorize")
 		}
 		return auth, err
@@ -113,17 +192,21 @@ func (a *Api) AuthorizationToken() (models.AuthoritionToken, error) {
 }
 
 func (a *Api) Login() (models.AuthoritionToken, models.ListAccountsResponse, error) {
-	auth, err := a.AuthorizationToken()
+	return a.LoginWithContext(context.Background())
+}
+
+func (a *Api) LoginWithContext(ctx context.Context) (models.AuthoritionToken, models.ListAccountsResponse, error) {
+	auth, err := a.AuthorizationTokenWithContext(ctx)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("AuthorizationToken")
 		}
 		return models.AuthoritionToken{}, models.ListAccountsResponse{}, err
 	}
 
-	acc, err := a.GetAccounts()
+	acc, err := a.GetAccountsWithContext(ctx)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("GetAccounts")
 		}
 		return models.AuthoritionToken{}, models.ListAccountsResponse{}, err
@@ -1,42 +1,53 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
-	"github.com/thiagozs/go-mbsdk/v4/config"
+	"github.com/google/go-querystring/query"
 	"github.com/thiagozs/go-mbsdk/v4/models"
-	"github.com/thiagozs/go-mbsdk/v4/pkg/caller"
 	"github.com/thiagozs/go-mbsdk/v4/pkg/replacer"
 )
 
 func (a *Api) GetBalances() (models.ListBalancesResponse, error) {
+	return a.GetBalancesWithContext(context.Background())
+}
+
+func (a *Api) GetBalancesWithContext(ctx context.Context) (models.ListBalancesResponse, error) {
 	balances := models.ListBalancesResponse{}
-	errApi := models.ErrorApiResponse{}
 
-	c, err := caller.ClientWithToken(http.MethodGet, a.cache)
+	if a.paper != nil {
+		balances, err := a.paper.Balances(ctx)
+		return balances, paperError(err)
+	}
+
+	accountID, err := a.accountID(ctx)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithToken")
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
 		}
 		return balances, err
 	}
 
 	endpoint, err := replacer.Endpoint(replacer.OptKey("BALANCE_LIST"),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
 		replacer.OptCache(a.cache),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return balances, err
 	}
 
-	res, err := c.GetWithResponse(endpoint)
+	res, err := a.doWithToken(ctx, "BALANCE_LIST", http.MethodGet, endpoint, nil)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
 		return balances, err
@@ -45,13 +56,13 @@ func (a *Api) GetBalances() (models.ListBalancesResponse, error) {
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return balances, err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -60,24 +71,22 @@ func (a *Api) GetBalances() (models.ListBalancesResponse, error) {
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return balances, err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return balances, fmt.Errorf("%s - %s", errApi.Code, errApi.Message)
+		return balances, err
 	}
 
 	if err := json.Unmarshal(bts, &balances); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal Balances")
 		}
 		return balances, err
 	}
 
 	if err := a.CacheSetBalance(balances); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("CacheSetBalance")
 		}
 		return balances, err
@@ -87,31 +96,27 @@ func (a *Api) GetBalances() (models.ListBalancesResponse, error) {
 }
 
 func (a *Api) GetAccounts() (models.ListAccountsResponse, error) {
+	return a.GetAccountsWithContext(context.Background())
+}
 
-	acc := models.ListAccountsResponse{}
-	errApi := models.ErrorApiResponse{}
+func (a *Api) GetAccountsWithContext(ctx context.Context) (models.ListAccountsResponse, error) {
 
-	c, err := caller.ClientWithToken(http.MethodGet, a.cache)
-	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithToken")
-		}
-		return acc, err
-	}
+	acc := models.ListAccountsResponse{}
 
 	endpoint, err := replacer.Endpoint(replacer.OptKey("ACCOUNTS"),
+		replacer.OptConfig(a.cfg),
 		replacer.OptCache(a.cache),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return acc, err
 	}
 
-	res, err := c.GetWithResponse(endpoint)
+	res, err := a.doWithToken(ctx, "ACCOUNTS", http.MethodGet, endpoint, nil)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
 		return acc, err
@@ -120,13 +125,13 @@ func (a *Api) GetAccounts() (models.ListAccountsResponse, error) {
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return acc, err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -135,24 +140,22 @@ func (a *Api) GetAccounts() (models.ListAccountsResponse, error) {
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return acc, err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return acc, fmt.Errorf("%s - %s", errApi.Code, errApi.Message)
+		return acc, err
 	}
 
 	if err := json.Unmarshal(bts, &acc); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal ListAccountsResponse")
 		}
 		return acc, err
 	}
 
 	if err := a.CacheSetAccounts(acc); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("SetAccounts")
 		}
 		return acc, err
@@ -160,3 +163,96 @@ func (a *Api) GetAccounts() (models.ListAccountsResponse, error) {
 
 	return acc, nil
 }
+
+func (a *Api) ListPositions(symbols []string) (models.ListPositionResponse, error) {
+	return a.ListPositionsWithContext(context.Background(), symbols)
+}
+
+// ListPositionsWithContext lists the open positions, only those of symbols
+// when any are given. Only the full list is cached.
+func (a *Api) ListPositionsWithContext(ctx context.Context, symbols []string) (models.ListPositionResponse, error) {
+	positions := models.ListPositionResponse{}
+
+	accountID, err := a.accountID(ctx)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
+		}
+		return positions, err
+	}
+
+	endpoint, err := replacer.Endpoint(replacer.OptKey("POSITION_LIST"),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
+		replacer.OptCache(a.cache),
+	)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("Replacer")
+		}
+		return positions, err
+	}
+
+	if len(symbols) > 0 {
+		v, err := query.Values(models.ListPositionQuery{Symbols: strings.Join(symbols, ",")})
+		if err != nil {
+			if a.cfg.Debug {
+				a.log.Error().Stack().Err(err).Msg("query.Values")
+			}
+			return positions, err
+		}
+		endpoint = fmt.Sprintf("%s?%s", endpoint, v.Encode())
+	}
+
+	res, err := a.doWithToken(ctx, "POSITION_LIST", http.MethodGet, endpoint, nil)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("Get")
+		}
+		return positions, err
+	}
+	defer res.Body.Close()
+
+	bts, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("ReadAll")
+		}
+		return positions, err
+	}
+
+	if a.cfg.Debug {
+		a.log.Info().
+			Str("endpoint", endpoint).
+			Int("status_code", res.StatusCode).
+			Str("body", string(bts)).
+			Msg("")
+	}
+
+	if res.StatusCode >= 400 {
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
+		}
+		return positions, err
+	}
+
+	if err := json.Unmarshal(bts, &positions); err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("Json Unmarshal ListPositionResponse")
+		}
+		return positions, err
+	}
+
+	// A filtered list would replace the cached positions with a subset.
+	if len(symbols) == 0 {
+		if err := a.CacheSetPositions(positions); err != nil {
+			if a.cfg.Debug {
+				a.log.Error().Stack().Err(err).Msg("CacheSetPositions")
+			}
+			return positions, err
+		}
+	}
+
+	return positions, nil
+}
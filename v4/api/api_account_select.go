@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/thiagozs/go-mbsdk/v4/config"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+type accountCtxKey struct{}
+
+// WithAccount returns a copy of ctx that makes the WithContext methods operate
+// on the account matched by opts instead of the active account.
+func WithAccount(ctx context.Context, opts ...AccountOptions) (context.Context, error) {
+	sel := AccountSelector{}
+	for _, op := range opts {
+		if err := op(&sel); err != nil {
+			return ctx, err
+		}
+	}
+	return context.WithValue(ctx, accountCtxKey{}, sel), nil
+}
+
+func (s AccountSelector) empty() bool {
+	return len(s.ID) == 0 && len(s.Name) == 0 && len(s.Currency) == 0
+}
+
+func (s AccountSelector) match(acc models.Account) bool {
+	if len(s.ID) > 0 && s.ID != acc.ID {
+		return false
+	}
+	if len(s.Name) > 0 && !strings.EqualFold(s.Name, acc.Name) {
+		return false
+	}
+	if len(s.Currency) > 0 && !strings.EqualFold(s.Currency, acc.Currency) {
+		return false
+	}
+	return true
+}
+
+// Accounts returns the accounts cached by Login or GetAccounts.
+func (a *Api) Accounts() (models.ListAccountsResponse, error) {
+	acc := models.ListAccountsResponse{}
+
+	val, err := a.cache.GetKeyVal(config.ACCOUNTS.String())
+	if err != nil {
+		return acc, fmt.Errorf("no accounts found, login first: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(val), &acc); err != nil {
+		return acc, err
+	}
+
+	return acc, nil
+}
+
+// ActiveAccount returns the account used by default on account endpoints.
+func (a *Api) ActiveAccount() (models.Account, error) {
+	a.accMu.RLock()
+	sel := a.account
+	a.accMu.RUnlock()
+
+	return a.resolveAccount(sel)
+}
+
+// SwitchAccount makes the account matched by opts the active account.
+func (a *Api) SwitchAccount(opts ...AccountOptions) (models.Account, error) {
+	sel := AccountSelector{}
+	for _, op := range opts {
+		if err := op(&sel); err != nil {
+			return models.Account{}, err
+		}
+	}
+
+	acc, err := a.resolveAccount(sel)
+	if err != nil {
+		return acc, err
+	}
+
+	a.accMu.Lock()
+	a.account = AccountSelector{ID: acc.ID}
+	a.accMu.Unlock()
+
+	return acc, nil
+}
+
+func (a *Api) resolveAccount(sel AccountSelector) (models.Account, error) {
+	accounts, err := a.Accounts()
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	if len(accounts) == 0 {
+		return models.Account{}, fmt.Errorf("no accounts found, login first")
+	}
+
+	if sel.empty() {
+		return accounts[0], nil
+	}
+
+	for _, acc := range accounts {
+		if sel.match(acc) {
+			return acc, nil
+		}
+	}
+
+	return models.Account{}, fmt.Errorf("account not found (id: %q name: %q currency: %q)",
+		sel.ID, sel.Name, sel.Currency)
+}
+
+// accountID returns the id to use on account endpoints for this call. An empty
+// id lets the replacer fall back to the first cached account.
+func (a *Api) accountID(ctx context.Context) (string, error) {
+	sel, ok := ctx.Value(accountCtxKey{}).(AccountSelector)
+	if !ok || sel.empty() {
+		a.accMu.RLock()
+		sel = a.account
+		a.accMu.RUnlock()
+	}
+
+	if sel.empty() {
+		return "", nil
+	}
+
+	if len(sel.Name) == 0 && len(sel.Currency) == 0 {
+		return sel.ID, nil
+	}
+
+	acc, err := a.resolveAccount(sel)
+	if err != nil {
+		return "", err
+	}
+
+	return acc.ID, nil
+}
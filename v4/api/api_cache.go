@@ -15,6 +15,10 @@ func (a *Api) CacheSetAccounts(acc models.ListAccountsResponse) error {
 	return a.cache.SetKeyValAsJSON(config.ACCOUNTS.String(), acc)
 }
 
+func (a *Api) CacheSetPositions(positions models.ListPositionResponse) error {
+	return a.cache.SetKeyValAsJSON(config.POSITIONS.String(), positions)
+}
+
 func (a *Api) CacheSetAuthorize(auth models.AuthoritionToken) error {
 	return a.cache.SetKeyValAsJSON(config.AUTHORIZE.String(), auth)
 }
@@ -0,0 +1,271 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// resolutions are the candle resolutions accepted by the exchange and the
+// duration of their bars. Monthly bars follow the calendar, see advance.
+var resolutions = map[string]time.Duration{
+	"1m":  time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"3h":  3 * time.Hour,
+	"1d":  24 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+	"1M":  0,
+}
+
+type BackfillOptions func(b *BackfillParameters) error
+
+type BackfillParameters struct {
+	Symbol      string
+	Resolution  string
+	From        time.Time
+	To          time.Time
+	Window      int
+	Concurrency int
+}
+
+func BfSymbol(symbol string) BackfillOptions {
+	return func(b *BackfillParameters) error {
+		b.Symbol = strings.ToUpper(symbol)
+		return nil
+	}
+}
+
+func BfResolution(resolution string) BackfillOptions {
+	return func(b *BackfillParameters) error {
+		if _, ok := resolutions[resolution]; !ok {
+			return fmt.Errorf("invalid resolution %q", resolution)
+		}
+		b.Resolution = resolution
+		return nil
+	}
+}
+
+func BfFrom(from time.Time) BackfillOptions {
+	return func(b *BackfillParameters) error {
+		b.From = from
+		return nil
+	}
+}
+
+func BfTo(to time.Time) BackfillOptions {
+	return func(b *BackfillParameters) error {
+		b.To = to
+		return nil
+	}
+}
+
+// BfWindow sets how many bars are asked in each request, 500 by default.
+func BfWindow(bars int) BackfillOptions {
+	return func(b *BackfillParameters) error {
+		if bars <= 0 {
+			return fmt.Errorf("invalid window %d", bars)
+		}
+		b.Window = bars
+		return nil
+	}
+}
+
+// BfConcurrency sets how many windows are fetched at once, 4 by default. The
+// requests still go through the rate limiter.
+func BfConcurrency(n int) BackfillOptions {
+	return func(b *BackfillParameters) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid concurrency %d", n)
+		}
+		b.Concurrency = n
+		return nil
+	}
+}
+
+// advance moves t by n bars of resolution.
+func advance(t time.Time, resolution string, n int) time.Time {
+	if resolution == "1M" {
+		return t.AddDate(0, n, 0)
+	}
+	return t.Add(time.Duration(n) * resolutions[resolution])
+}
+
+func (a *Api) BackfillCandles(opts ...BackfillOptions) (models.CandleHistory, error) {
+	return a.BackfillCandlesWithContext(context.Background(), opts...)
+}
+
+// BackfillCandlesWithContext fetches the candles between From and To, splitting
+// the range in windows of Window bars fetched concurrently. The bars are
+// returned once each, oldest first, and the missing ones are reported as gaps.
+func (a *Api) BackfillCandlesWithContext(ctx context.Context, opts ...BackfillOptions) (models.CandleHistory, error) {
+	params := &BackfillParameters{Window: 500, Concurrency: 4}
+
+	for _, op := range opts {
+		err := op(params)
+		if err != nil {
+			return models.CandleHistory{}, err
+		}
+	}
+
+	if params.Symbol == "" {
+		return models.CandleHistory{}, fmt.Errorf("parameters 'symbol' is required")
+	}
+
+	if params.Resolution == "" {
+		return models.CandleHistory{}, fmt.Errorf("parameters 'resolution' is required")
+	}
+
+	if params.From.IsZero() || params.To.IsZero() || !params.From.Before(params.To) {
+		return models.CandleHistory{}, fmt.Errorf("parameters 'from' and 'to' must be a valid range")
+	}
+
+	params.From = params.From.UTC()
+	params.To = params.To.UTC()
+
+	history := models.CandleHistory{
+		Symbol:     params.Symbol,
+		Resolution: params.Resolution,
+		Bars:       []models.Bar{},
+		Gaps:       []models.Gap{},
+	}
+
+	type window struct{ from, to time.Time }
+	windows := []window{}
+	for start := params.From; start.Before(params.To); {
+		end := advance(start, params.Resolution, params.Window)
+		if end.After(params.To) {
+			end = params.To
+		}
+		windows = append(windows, window{start, end})
+		start = end
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		bars     = map[int64]models.Bar{}
+	)
+
+	sem := make(chan struct{}, params.Concurrency)
+	for _, w := range windows {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(w window) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			candles, err := a.CandlesWithContext(ctx,
+				CandSymbols(params.Symbol),
+				CandResolution(params.Resolution),
+				CandFrom(int(w.from.Unix())),
+				CandTo(int(w.to.Unix())),
+			)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("candles %s - %s: %w", w.from, w.to, err)
+					cancel()
+				}
+				return
+			}
+
+			for _, c := range candles {
+				t := time.Unix(int64(c.Timestamp), 0).UTC()
+				if t.Before(params.From) || !t.Before(params.To) {
+					continue
+				}
+				bars[t.Unix()] = models.Bar{
+					Time:   t,
+					Open:   c.Open,
+					High:   c.High,
+					Low:    c.Low,
+					Close:  c.Close,
+					Volume: c.Volume,
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(firstErr).Msg("BackfillCandles")
+		}
+		return history, firstErr
+	}
+
+	for _, bar := range bars {
+		history.Bars = append(history.Bars, bar)
+	}
+	sort.Slice(history.Bars, func(i, j int) bool { return history.Bars[i].Time.Before(history.Bars[j].Time) })
+
+	history.Gaps = gaps(history.Bars, params.Resolution, params.From, params.To)
+
+	if a.cfg.Debug {
+		a.log.Debug().
+			Str("symbol", params.Symbol).
+			Str("resolution", params.Resolution).
+			Int("windows", len(windows)).
+			Int("bars", len(history.Bars)).
+			Int("gaps", len(history.Gaps)).
+			Msg("BackfillCandles")
+	}
+
+	return history, nil
+}
+
+// gaps lists the runs of bars missing from the sorted bars within [from, to).
+// Bars are expected every step after the first one received, and before it
+// back to from.
+func gaps(bars []models.Bar, resolution string, from, to time.Time) []models.Gap {
+	found := []models.Gap{}
+	if len(bars) == 0 {
+		end := from
+		for advance(end, resolution, 1).Before(to) {
+			end = advance(end, resolution, 1)
+		}
+		return append(found, models.Gap{From: from, To: end})
+	}
+
+	first := bars[0].Time
+	if start := advance(first, resolution, -1); !start.Before(from) {
+		for !advance(start, resolution, -1).Before(from) {
+			start = advance(start, resolution, -1)
+		}
+		found = append(found, models.Gap{From: start, To: advance(first, resolution, -1)})
+	}
+
+	for i := 1; i < len(bars); i++ {
+		next := advance(bars[i-1].Time, resolution, 1)
+		if next.Before(bars[i].Time) {
+			found = append(found, models.Gap{From: next, To: advance(bars[i].Time, resolution, -1)})
+		}
+	}
+
+	last := bars[len(bars)-1].Time
+	if next := advance(last, resolution, 1); next.Before(to) {
+		end := next
+		for advance(end, resolution, 1).Before(to) {
+			end = advance(end, resolution, 1)
+		}
+		found = append(found, models.Gap{From: next, To: end})
+	}
+
+	return found
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+var candlesStart = time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// minute returns the start of the n-th one minute bar after candlesStart.
+func minute(n int) time.Time {
+	return candlesStart.Add(time.Duration(n) * time.Minute)
+}
+
+// setMinutes serves one minute candles for the bars from first to last,
+// leaving out the ones in missing.
+func setMinutes(t *testing.T, srv *mockserver.Server, first, last int, missing ...int) {
+	t.Helper()
+
+	skip := map[int]bool{}
+	for _, n := range missing {
+		skip[n] = true
+	}
+
+	candles := []mockserver.Candle{}
+	for n := first; n <= last; n++ {
+		if !skip[n] {
+			candles = append(candles, mockserver.Candle{Timestamp: minute(n), Open: "1", High: "1", Low: "1", Close: "1", Volume: "1"})
+		}
+	}
+	if err := srv.SetCandles("BTC-BRL", candles); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func backfillMinutes(ctx context.Context, a *Api, from, to int, opts ...BackfillOptions) (models.CandleHistory, error) {
+	return a.BackfillCandlesWithContext(ctx, append([]BackfillOptions{
+		BfSymbol("BTC-BRL"),
+		BfResolution("1m"),
+		BfFrom(minute(from)),
+		BfTo(minute(to)),
+	}, opts...)...)
+}
+
+func TestBackfillWindows(t *testing.T) {
+	a, srv := newMockApi(t)
+	setMinutes(t, srv, 0, 9)
+
+	history, err := backfillMinutes(context.Background(), a, 0, 10, BfWindow(3), BfConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3 + 3 + 3 + 1 bars.
+	if calls := srv.Calls("CANDLES"); calls != 4 {
+		t.Errorf("%d requests, want 4", calls)
+	}
+	if len(history.Bars) != 10 {
+		t.Fatalf("%d bars, want 10", len(history.Bars))
+	}
+	for i, bar := range history.Bars {
+		if !bar.Time.Equal(minute(i)) {
+			t.Errorf("bar %d at %s, want %s", i, bar.Time, minute(i))
+		}
+	}
+	if len(history.Gaps) != 0 {
+		t.Errorf("unexpected gaps %v", history.Gaps)
+	}
+}
+
+func TestBackfillHalfOpenRange(t *testing.T) {
+	a, srv := newMockApi(t)
+	// The server has bars before From and at To.
+	setMinutes(t, srv, -3, 8)
+
+	history, err := backfillMinutes(context.Background(), a, 0, 5, BfWindow(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history.Bars) != 5 {
+		t.Fatalf("%d bars, want 5", len(history.Bars))
+	}
+	if first := history.Bars[0].Time; !first.Equal(minute(0)) {
+		t.Errorf("first bar at %s, want From %s", first, minute(0))
+	}
+	if last := history.Bars[4].Time; !last.Equal(minute(4)) {
+		t.Errorf("last bar at %s, want %s, before To", last, minute(4))
+	}
+}
+
+func TestBackfillGaps(t *testing.T) {
+	a, srv := newMockApi(t)
+	setMinutes(t, srv, 0, 9, 0, 4, 5, 9)
+
+	history, err := backfillMinutes(context.Background(), a, 0, 10, BfWindow(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Bars) != 6 {
+		t.Errorf("%d bars, want 6", len(history.Bars))
+	}
+
+	want := []models.Gap{
+		{From: minute(0), To: minute(0)},
+		{From: minute(4), To: minute(5)},
+		{From: minute(9), To: minute(9)},
+	}
+	if len(history.Gaps) != len(want) {
+		t.Fatalf("gaps %v, want %v", history.Gaps, want)
+	}
+	for i, g := range want {
+		if !history.Gaps[i].From.Equal(g.From) || !history.Gaps[i].To.Equal(g.To) {
+			t.Errorf("gap %d is %s - %s, want %s - %s", i, history.Gaps[i].From, history.Gaps[i].To, g.From, g.To)
+		}
+	}
+
+	// Without any bar the whole range is a gap.
+	setMinutes(t, srv, 20, 30)
+	history, err = backfillMinutes(context.Background(), a, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Gaps) != 1 || !history.Gaps[0].From.Equal(minute(0)) || !history.Gaps[0].To.Equal(minute(9)) {
+		t.Errorf("gaps %v, want %s - %s", history.Gaps, minute(0), minute(9))
+	}
+}
+
+func TestBackfillCancel(t *testing.T) {
+	a, srv := newMockApi(t)
+	setMinutes(t, srv, 0, 9)
+	srv.Fail("CANDLES", mockserver.Failure{Delay: 5 * time.Second, Times: 10})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := backfillMinutes(ctx, a, 0, 10, BfWindow(1), BfConcurrency(2))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("returned after %s, not when cancelled", elapsed)
+	}
+	// No window is started after the cancellation.
+	if calls := srv.Calls("CANDLES"); calls > 2 {
+		t.Errorf("%d requests, want at most the 2 in flight", calls)
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// Sentinel errors matched by APIError through errors.Is.
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrInvalidSymbol       = errors.New("invalid symbol")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrOrderNotFound       = errors.New("order not found")
+)
+
+// ErrOrderStateUnknown is returned when an order request failed without an
+// answer and the order could not be looked up afterwards. It may or may not
+// exist; search it by the external id in the result before sending it again.
+var ErrOrderStateUnknown = errors.New("order state unknown")
+
+// APIError is returned when the exchange answers with a status code >= 400.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Body       []byte
+	Endpoint   string
+}
+
+func newAPIError(endpoint string, statusCode int, body []byte) *APIError {
+	errApi := models.ErrorApiResponse{}
+	_ = json.Unmarshal(body, &errApi)
+
+	return &APIError{
+		StatusCode: statusCode,
+		Code:       errApi.Code,
+		Message:    errApi.Message,
+		Body:       body,
+		Endpoint:   endpoint,
+	}
+}
+
+func (e *APIError) Error() string {
+	if len(e.Code) == 0 && len(e.Message) == 0 {
+		return fmt.Sprintf("%d - %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if len(e.Code) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s - %s", e.Code, e.Message)
+}
+
+// Is classifies the error by status code and by the code returned in the body,
+// so callers can use errors.Is(err, api.ErrRateLimited) and friends.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests ||
+			e.codeContains("RATE_LIMIT", "TOO_MANY_REQUESTS")
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized ||
+			e.codeContains("UNAUTHORIZED", "INVALID_TOKEN", "FORBIDDEN")
+	case ErrInsufficientBalance:
+		return e.codeContains("INSUFFICIENT_BALANCE", "INSUFFICIENT_FUNDS")
+	case ErrInvalidSymbol:
+		return e.codeContains("INVALID_SYMBOL", "SYMBOL_NOT_FOUND", "INVALID_INSTRUMENT")
+	case ErrOrderNotFound:
+		return e.codeContains("ORDER_NOT_FOUND") ||
+			(e.StatusCode == http.StatusNotFound && strings.Contains(e.Endpoint, "/orders/"))
+	}
+	return false
+}
+
+func (e *APIError) codeContains(values ...string) bool {
+	code := strings.ToUpper(e.Code)
+	for _, v := range values {
+		if strings.Contains(code, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// BatchError collects the failures of a batch call by id. errors.Is matches
+// when any of them matches.
+type BatchError struct {
+	Total  int
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	msgs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", id, e.Errors[id]))
+	}
+	return fmt.Sprintf("%d of %d failed: %s", len(e.Errors), e.Total, strings.Join(msgs, "; "))
+}
+
+func (e *BatchError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/config"
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+func balanceOf(t *testing.T, a *Api, symbol string) models.Balance {
+	t.Helper()
+
+	balances, err := a.GetBalances()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range balances {
+		if b.Symbol == symbol {
+			return b
+		}
+	}
+	return models.Balance{Symbol: symbol}
+}
+
+func assertDecimal(t *testing.T, what string, got interface{ String() string }, want string) {
+	t.Helper()
+	if got.String() != want {
+		t.Errorf("%s is %s, want %s", what, got, want)
+	}
+}
+
+func TestLoginAndTokenRefresh(t *testing.T) {
+	srv, err := mockserver.New(mockserver.OptCredentials("key", "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	bad, err := New(OptKey("key"), OptSecret("wrong"), OptEndpoint(srv.URL()), OptRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := bad.Login(); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+
+	a, err := New(OptKey("key"), OptSecret("secret"), OptEndpoint(srv.URL()), OptRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, accounts, err := a.Login()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token.AccessToken) == 0 {
+		t.Error("empty access token")
+	}
+	if len(accounts) != 1 || accounts[0].ID != mockserver.DefaultAccountID {
+		t.Fatalf("unexpected accounts %+v", accounts)
+	}
+	authorizations := srv.Calls("AUTHORIZE")
+
+	if _, err := a.GetBalances(); err != nil {
+		t.Fatal(err)
+	}
+	if calls := srv.Calls("AUTHORIZE"); calls != authorizations {
+		t.Errorf("authorized again with a valid token")
+	}
+
+	// The API answers 401 once and the call goes through with a new token.
+	srv.ExpireTokens()
+	if _, err := a.GetBalances(); err != nil {
+		t.Fatal(err)
+	}
+	if calls := srv.Calls("AUTHORIZE"); calls != authorizations+1 {
+		t.Errorf("%d authorizations after the token expired, want %d", calls, authorizations+1)
+	}
+	if calls := srv.Calls("BALANCE_LIST"); calls != 3 {
+		t.Errorf("%d balance calls, want 3", calls)
+	}
+}
+
+func TestMockBalances(t *testing.T) {
+	a, srv := newMockApi(t)
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1500.50"); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BTC", "0.25"); err != nil {
+		t.Fatal(err)
+	}
+
+	brl := balanceOf(t, a, "BRL")
+	assertDecimal(t, "BRL available", brl.Available, "1500.5")
+	assertDecimal(t, "BRL total", brl.Total, "1500.5")
+	assertDecimal(t, "BTC available", balanceOf(t, a, "BTC").Available, "0.25")
+}
+
+func TestMockOrderMatchingAndCancel(t *testing.T) {
+	a, srv := newMockApi(t)
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000000"); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range [][2]string{{"150000", "1"}, {"151000", "1"}} {
+		if err := srv.AddLiquidity("BTC-BRL", "sell", l[0], l[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A market buy walks both levels.
+	result, err := a.SubmitOrder(PoSymbol("BTC-BRL"), PoKind(BUY), PoType("market"), PoQty("1.5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := a.GetOrder("BTC-BRL", result.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "filled" || len(order.Executions) != 2 {
+		t.Fatalf("order %s with %d executions, want filled with 2", order.Status, len(order.Executions))
+	}
+	assertDecimal(t, "filled quantity", order.FilledQty, "1.5")
+	assertDecimal(t, "BTC available", balanceOf(t, a, "BTC").Available, "1.5")
+	assertDecimal(t, "BRL available", balanceOf(t, a, "BRL").Available, "774500")
+
+	// A limit buy below the book rests and holds its cost until cancelled.
+	result, err = a.SubmitOrder(PoSymbol("BTC-BRL"), PoKind(BUY), PoType("limit"), PoPrice("140000"), PoQty("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	brl := balanceOf(t, a, "BRL")
+	assertDecimal(t, "BRL on hold", brl.OnHold, "140000")
+
+	book, err := a.OrderBook("BTC-BRL", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) != 1 || book.Bids[0].Price.String() != "140000" {
+		t.Errorf("unexpected bids %v", book.Bids)
+	}
+
+	if err := a.CancelOrder("BTC-BRL", result.OrderID); err != nil {
+		t.Fatal(err)
+	}
+
+	order, err = a.GetOrder("BTC-BRL", result.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "cancelled" {
+		t.Errorf("order %s after cancel", order.Status)
+	}
+	brl = balanceOf(t, a, "BRL")
+	assertDecimal(t, "BRL on hold after cancel", brl.OnHold, "0")
+	assertDecimal(t, "BRL available after cancel", brl.Available, "774500")
+
+	if err := a.CancelOrder("BTC-BRL", "missing"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestMockPositions(t *testing.T) {
+	a, srv := newMockApi(t)
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000"); err != nil {
+		t.Fatal(err)
+	}
+	id, _, err := placeBuy(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	positions, err := a.ListPositions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 1 || positions[0].ID != id {
+		t.Fatalf("positions %+v, want order %s", positions, id)
+	}
+	cached, err := a.cache.GetKeyVal(config.POSITIONS.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A filtered list leaves the cached positions alone.
+	positions, err = a.ListPositions([]string{"ETH-BRL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 0 {
+		t.Errorf("positions %+v, want none for ETH-BRL", positions)
+	}
+	if got, err := a.cache.GetKeyVal(config.POSITIONS.String()); err != nil || got != cached {
+		t.Errorf("cache changed to %s (%v), want %s", got, err, cached)
+	}
+}
+
+func TestMockWithdraw(t *testing.T) {
+	a, srv := newMockApi(t)
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BTC", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := a.WalletWithdrawCoin(WalletCoinSymbol("BTC"), WalletCoinAddr("bc1qtest"), WalletCoinQty("0.4"), WalletCoinTxFee("0.0001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDecimal(t, "net quantity", wd.NetQuantity, "0.3999")
+	assertDecimal(t, "BTC after withdrawal", balanceOf(t, a, "BTC").Available, "0.6")
+
+	got, err := a.WalletGetWithdrawCoin("BTC", strconv.Itoa(wd.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.WithdrawStatus() != models.WithdrawPending {
+		t.Errorf("withdrawal is %s, want pending", got.WithdrawStatus())
+	}
+
+	if err := srv.SetWithdrawStatus(wd.ID, 3, ""); err != nil {
+		t.Fatal(err)
+	}
+	got, err = a.WalletGetWithdrawCoin("BTC", strconv.Itoa(wd.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.WithdrawStatus() != models.WithdrawFailed {
+		t.Errorf("withdrawal is %s, want failed", got.WithdrawStatus())
+	}
+	assertDecimal(t, "BTC after refund", balanceOf(t, a, "BTC").Available, "1")
+
+	_, err = a.WalletWithdrawCoin(WalletCoinSymbol("BTC"), WalletCoinAddr("bc1qtest"), WalletCoinQty("2"))
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected ErrInsufficientBalance, got %v", err)
+	}
+}
+
+func TestMockScriptedFailures(t *testing.T) {
+	a, srv := newMockApi(t)
+
+	srv.Fail("BALANCE_LIST", mockserver.Failure{Status: http.StatusBadRequest, Code: "API|BROKEN", Message: "scripted"})
+	_, err := a.GetBalances()
+
+	apiErr := &APIError{}
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "API|BROKEN" {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+
+	// The failure is used once.
+	if _, err := a.GetBalances(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Server errors are retried by the transport.
+	srv.Fail("BALANCE_LIST", mockserver.Failure{Status: http.StatusInternalServerError, Times: 2})
+	if _, err := a.GetBalances(); err != nil {
+		t.Fatalf("retries did not get past two 500: %v", err)
+	}
+
+	// Failures outlasting the retries keep the answer of the server.
+	for _, f := range []mockserver.Failure{
+		{Status: http.StatusTooManyRequests, Code: "API|RATE_LIMIT", Message: "slow down", Times: 3},
+		{Status: http.StatusServiceUnavailable, Code: "API|UNAVAILABLE", Message: "maintenance", Times: 3},
+	} {
+		calls := srv.Calls("BALANCE_LIST")
+		srv.Fail("BALANCE_LIST", f)
+		_, err := a.GetBalances()
+
+		apiErr := &APIError{}
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("%d: expected an APIError, got %v", f.Status, err)
+		}
+		if apiErr.StatusCode != f.Status || apiErr.Code != f.Code || apiErr.Message != f.Message {
+			t.Errorf("unexpected error %+v", apiErr)
+		}
+		if limited := errors.Is(err, ErrRateLimited); limited != (f.Status == http.StatusTooManyRequests) {
+			t.Errorf("%d: errors.Is(err, ErrRateLimited) is %v", f.Status, limited)
+		}
+		if n := srv.Calls("BALANCE_LIST") - calls; n != 3 {
+			t.Errorf("%d: %d attempts, want 3", f.Status, n)
+		}
+	}
+
+	srv.Fail("BALANCE_LIST", mockserver.Failure{Delay: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := a.GetBalancesWithContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/paper"
+)
+
+// paperEndpoint is reported as the endpoint of paper trading results and
+// errors.
+const paperEndpoint = "paper"
+
+// PaperAccount returns the simulated account, or nil when paper trading is
+// disabled.
+func (a *Api) PaperAccount() *paper.Account {
+	return a.paper
+}
+
+// paperError converts the rejections of the simulated account to *APIError,
+// so callers handle them as the exchange ones.
+func paperError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	perr := &paper.Error{}
+	if !errors.As(err, &perr) {
+		return err
+	}
+
+	body, _ := json.Marshal(models.ErrorApiResponse{Code: perr.Code, Message: perr.Message})
+	return &APIError{
+		StatusCode: perr.Status,
+		Code:       perr.Code,
+		Message:    perr.Message,
+		Body:       body,
+		Endpoint:   paperEndpoint,
+	}
+}
+
+func (a *Api) paperSubmit(ctx context.Context, symbol string, order models.PlaceOrderPayload,
+	result models.PlaceOrderResult) (models.PlaceOrderResult, error) {
+
+	result.EndPoint = paperEndpoint
+
+	placed, err := a.paper.Place(ctx, symbol, order)
+	if err != nil {
+		err = paperError(err)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("paper Place")
+		}
+		return result, err
+	}
+
+	resp := models.PlaceOrderResponse{OrderID: placed.ID}
+	result.OrderID = placed.ID
+	result.StatusCode = http.StatusOK
+	result.Response = string(resp.ToBytes())
+
+	if a.cfg.Debug {
+		a.log.Debug().
+			Str("endpoint", paperEndpoint).
+			Str("order_id", placed.ID).
+			Str("status", placed.Status).
+			Msg("")
+	}
+
+	if err := a.CacheSetOrder([]models.OrdersIndex{
+		{
+			Symbol: symbol,
+			ID:     placed.ID,
+			Price:  order.LimitPrice.String(),
+			Side:   order.Side,
+			Type:   order.Type,
+		},
+	}); err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("Cache SetOrder")
+		}
+		return result, err
+	}
+
+	return result, nil
+}
+
+// paperOrders applies the ListOrders filters to the simulated orders.
+func (a *Api) paperOrders(ctx context.Context, symbol string, params *OrdersPameters) (models.ListOrderResponse, error) {
+	orders, err := a.paper.Orders(ctx, symbol)
+	if err != nil {
+		return models.ListOrderResponse{}, paperError(err)
+	}
+
+	filtered := models.ListOrderResponse{}
+	for _, o := range orders {
+		switch {
+		case len(params.Side) > 0 && o.Side != params.Side:
+			continue
+		case len(params.Status) > 0 && o.Status != params.Status:
+			continue
+		case params.HasExecutions == "true" && len(o.Executions) == 0:
+			continue
+		case params.HasExecutions == "false" && len(o.Executions) > 0:
+			continue
+		case !inRange(o.ID, params.IdFrom, params.IdTo):
+			continue
+		case !inRange(strconv.Itoa(o.CreatedAt), params.CreatedFrom, params.createdTo):
+			continue
+		}
+		filtered = append(filtered, o)
+	}
+
+	return filtered, nil
+}
+
+// inRange reports whether the numeric value is within the optional bounds.
+func inRange(value, from, to string) bool {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return true
+	}
+	if f, err := strconv.ParseInt(from, 10, 64); err == nil && v < f {
+		return false
+	}
+	if t, err := strconv.ParseInt(to, 10, 64); err == nil && v > t {
+		return false
+	}
+	return true
+}
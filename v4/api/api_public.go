@@ -1,15 +1,16 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 
 	"github.com/google/go-querystring/query"
-	"github.com/thiagozs/go-mbsdk/v4/config"
 	"github.com/thiagozs/go-mbsdk/v4/models"
 	"github.com/thiagozs/go-mbsdk/v4/pkg/caller"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/ratelimit"
 	"github.com/thiagozs/go-mbsdk/v4/pkg/replacer"
 )
 
@@ -58,40 +59,71 @@ func CandCountBack(countback int) CandlesOptions {
 	}
 }
 
+// doPublic sends an unauthenticated GET. key is the config.EndPoints name of
+// the request, used for rate limiting.
+func (a *Api) doPublic(ctx context.Context, key, endpoint string) (*http.Response, error) {
+	req := caller.RequestPublic(http.MethodGet, endpoint)
+	return a.rest.Do(ratelimit.WithKey(ctx, key), req)
+}
+
 func (a *Api) Tickers(symbol string) (models.TickersResponse, error) {
-	tickers := models.TickersResponse{}
+	return a.TickersWithContext(context.Background(), symbol)
+}
 
-	c, err := caller.ClientPublic(http.MethodGet, a.cache)
-	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientPublic")
-		}
-		return tickers, err
-	}
+func (a *Api) TickersWithContext(ctx context.Context, symbol string) (models.TickersResponse, error) {
+	tickers := models.TickersResponse{}
 
 	v, _ := query.Values(models.TickersQuery{Symbols: symbol})
 	endpoint, err := replacer.Endpoint(
 		replacer.OptKey("TICKERS"),
+		replacer.OptConfig(a.cfg),
 		replacer.OptSymbol(symbol),
 		replacer.OptCache(a.cache),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return tickers, err
 	}
 
-	bts, err := c.Get(fmt.Sprintf("%s?%s", endpoint, v.Encode()))
+	endpoint = fmt.Sprintf("%s?%s", endpoint, v.Encode())
+
+	res, err := a.doPublic(ctx, "TICKERS", endpoint)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
 		return tickers, err
 	}
+	defer res.Body.Close()
+
+	bts, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("ReadAll")
+		}
+		return tickers, err
+	}
+
+	if a.cfg.Debug {
+		a.log.Info().
+			Str("endpoint", endpoint).
+			Int("status_code", res.StatusCode).
+			Str("body", string(bts)).
+			Msg("")
+	}
+
+	if res.StatusCode >= 400 {
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
+		}
+		return tickers, err
+	}
 
 	if err := json.Unmarshal(bts, &tickers); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal Tickers")
 		}
 		return tickers, err
@@ -101,24 +133,20 @@ func (a *Api) Tickers(symbol string) (models.TickersResponse, error) {
 }
 
 func (a *Api) OrderBook(symbol, limit string) (models.OrderBookResponse, error) {
-	orderbook := models.OrderBookResponse{}
-	errApi := models.ErrorApiResponse{}
+	return a.OrderBookWithContext(context.Background(), symbol, limit)
+}
 
-	c, err := caller.ClientPublic(http.MethodGet, a.cache)
-	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientPublic")
-		}
-		return orderbook, err
-	}
+func (a *Api) OrderBookWithContext(ctx context.Context, symbol, limit string) (models.OrderBookResponse, error) {
+	orderbook := models.OrderBookResponse{}
 
 	endpoint, err := replacer.Endpoint(
 		replacer.OptKey("ORDERBOOK"),
+		replacer.OptConfig(a.cfg),
 		replacer.OptSymbol(symbol),
 		replacer.OptCache(a.cache),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return orderbook, err
@@ -129,9 +157,9 @@ func (a *Api) OrderBook(symbol, limit string) (models.OrderBookResponse, error)
 		endpoint = fmt.Sprintf("%s?%s", endpoint, v.Encode())
 	}
 
-	res, err := c.GetWithResponse(endpoint)
+	res, err := a.doPublic(ctx, "ORDERBOOK", endpoint)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
 		return orderbook, err
@@ -140,13 +168,13 @@ func (a *Api) OrderBook(symbol, limit string) (models.OrderBookResponse, error)
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return orderbook, err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -155,17 +183,15 @@ func (a *Api) OrderBook(symbol, limit string) (models.OrderBookResponse, error)
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return orderbook, err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return orderbook, fmt.Errorf("%s", errApi.Message)
+		return orderbook, err
 	}
 
 	if err := json.Unmarshal(bts, &orderbook); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal orderbook")
 		}
 		return orderbook, err
@@ -175,32 +201,28 @@ func (a *Api) OrderBook(symbol, limit string) (models.OrderBookResponse, error)
 }
 
 func (a *Api) Trades(symbol string) (models.TradesResponse, error) {
-	trades := models.TradesResponse{}
-	errApi := models.ErrorApiResponse{}
+	return a.TradesWithContext(context.Background(), symbol)
+}
 
-	c, err := caller.ClientPublic(http.MethodGet, a.cache)
-	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientPublic")
-		}
-		return trades, err
-	}
+func (a *Api) TradesWithContext(ctx context.Context, symbol string) (models.TradesResponse, error) {
+	trades := models.TradesResponse{}
 
 	endpoint, err := replacer.Endpoint(
 		replacer.OptKey("TRADES"),
+		replacer.OptConfig(a.cfg),
 		replacer.OptSymbol(symbol),
 		replacer.OptCache(a.cache),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return trades, err
 	}
 
-	res, err := c.GetWithResponse(endpoint)
+	res, err := a.doPublic(ctx, "TRADES", endpoint)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
 		return trades, err
@@ -209,13 +231,13 @@ func (a *Api) Trades(symbol string) (models.TradesResponse, error) {
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return trades, err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -224,17 +246,15 @@ func (a *Api) Trades(symbol string) (models.TradesResponse, error) {
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return trades, err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return trades, fmt.Errorf("%s", errApi.Message)
+		return trades, err
 	}
 
 	if err := json.Unmarshal(bts, &trades); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal trades")
 		}
 		return trades, err
@@ -244,23 +264,19 @@ func (a *Api) Trades(symbol string) (models.TradesResponse, error) {
 }
 
 func (a *Api) Symbols(symbol []string) (models.SymbolsResponse, error) {
-	symbols := models.SymbolsResponse{}
-	errApi := models.ErrorApiResponse{}
+	return a.SymbolsWithContext(context.Background(), symbol)
+}
 
-	c, err := caller.ClientPublic(http.MethodGet, a.cache)
-	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientPublic")
-		}
-		return symbols, err
-	}
+func (a *Api) SymbolsWithContext(ctx context.Context, symbol []string) (models.SymbolsResponse, error) {
+	symbols := models.SymbolsResponse{}
 
 	endpoint, err := replacer.Endpoint(
 		replacer.OptKey("SYMBOLS"),
+		replacer.OptConfig(a.cfg),
 		replacer.OptCache(a.cache),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return symbols, err
@@ -271,9 +287,9 @@ func (a *Api) Symbols(symbol []string) (models.SymbolsResponse, error) {
 		endpoint = fmt.Sprintf("%s?%s", endpoint, v.Encode())
 	}
 
-	res, err := c.GetWithResponse(endpoint)
+	res, err := a.doPublic(ctx, "SYMBOLS", endpoint)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
 		return symbols, err
@@ -282,13 +298,13 @@ func (a *Api) Symbols(symbol []string) (models.SymbolsResponse, error) {
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return symbols, err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -297,17 +313,15 @@ func (a *Api) Symbols(symbol []string) (models.SymbolsResponse, error) {
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return symbols, err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return symbols, fmt.Errorf("%s", errApi.Message)
+		return symbols, err
 	}
 
 	if err := json.Unmarshal(bts, &symbols); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal symbols")
 		}
 		return symbols, err
@@ -317,8 +331,11 @@ func (a *Api) Symbols(symbol []string) (models.SymbolsResponse, error) {
 }
 
 func (a *Api) Candles(opts ...CandlesOptions) (models.CandlesResponse, error) {
+	return a.CandlesWithContext(context.Background(), opts...)
+}
+
+func (a *Api) CandlesWithContext(ctx context.Context, opts ...CandlesOptions) (models.CandlesResponse, error) {
 	candles := models.CandlesResponse{}
-	errApi := models.ErrorApiResponse{}
 	params := &CandlesParameters{}
 
 	for _, op := range opts {
@@ -344,20 +361,13 @@ func (a *Api) Candles(opts ...CandlesOptions) (models.CandlesResponse, error) {
 		return candles, fmt.Errorf("parameters 'from' is required")
 	}
 
-	c, err := caller.ClientPublic(http.MethodGet, a.cache)
-	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientPublic")
-		}
-		return candles, err
-	}
-
 	endpoint, err := replacer.Endpoint(
 		replacer.OptKey("CANDLES"),
+		replacer.OptConfig(a.cfg),
 		replacer.OptCache(a.cache),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return candles, err
@@ -366,9 +376,9 @@ func (a *Api) Candles(opts ...CandlesOptions) (models.CandlesResponse, error) {
 	v, _ := query.Values(params)
 	endpoint = fmt.Sprintf("%s?%s", endpoint, v.Encode())
 
-	res, err := c.GetWithResponse(endpoint)
+	res, err := a.doPublic(ctx, "CANDLES", endpoint)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
 		return candles, err
@@ -377,13 +387,13 @@ func (a *Api) Candles(opts ...CandlesOptions) (models.CandlesResponse, error) {
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return candles, err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -392,17 +402,15 @@ func (a *Api) Candles(opts ...CandlesOptions) (models.CandlesResponse, error) {
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return candles, err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return candles, fmt.Errorf("%s", errApi.Message)
+		return candles, err
 	}
 
 	if err := json.Unmarshal(bts, &candles); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal candles")
 		}
 		return candles, err
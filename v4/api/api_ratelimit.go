@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/thiagozs/go-mbsdk/v4/pkg/client"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/ratelimit"
+)
+
+// limit routes every attempt of c through the rate limiter. The bucket is
+// picked from the endpoint key stored with ratelimit.WithKey in the request
+// context.
+func (a *Api) limit(c client.HttpClientPort) {
+	if a.limiter == nil {
+		return
+	}
+	c.WrapTransport(a.limiter.Transport)
+}
+
+// RateLimitBudget returns the requests currently available in each endpoint
+// group, or nil when client-side limiting is disabled.
+func (a *Api) RateLimitBudget() map[ratelimit.Group]ratelimit.Budget {
+	if a.limiter == nil {
+		return nil
+	}
+	return a.limiter.Budget()
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// RefreshSymbols loads the symbol registry from the Symbols endpoint.
+func (a *Api) RefreshSymbols() error {
+	return a.RefreshSymbolsWithContext(context.Background())
+}
+
+func (a *Api) RefreshSymbolsWithContext(ctx context.Context) error {
+	symbols, err := a.SymbolsWithContext(ctx, nil)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("Symbols")
+		}
+		return err
+	}
+
+	index, err := symbols.BySymbol()
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("BySymbol")
+		}
+		return err
+	}
+
+	a.symMu.Lock()
+	a.symbols = index
+	a.symbolsAt = time.Now()
+	a.symMu.Unlock()
+
+	if a.cfg.Debug {
+		a.log.Debug().Int("symbols", len(index)).Msg("RefreshSymbols")
+	}
+
+	return nil
+}
+
+// loadSymbols refreshes the registry once it is older than the symbols ttl.
+// When the refresh fails the previous registry is kept, if any.
+func (a *Api) loadSymbols(ctx context.Context) error {
+	a.symMu.RLock()
+	fresh := a.symbols != nil && time.Since(a.symbolsAt) < a.symbolsTTL
+	a.symMu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	a.symRefreshMu.Lock()
+	defer a.symRefreshMu.Unlock()
+
+	a.symMu.RLock()
+	loaded, at := a.symbols != nil, a.symbolsAt
+	a.symMu.RUnlock()
+	if loaded && time.Since(at) < a.symbolsTTL {
+		return nil
+	}
+
+	if err := a.RefreshSymbolsWithContext(ctx); err != nil {
+		if loaded {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// SymbolInfo returns the record of symbol from the symbol registry, loaded
+// on first use and refreshed periodically.
+func (a *Api) SymbolInfo(symbol string) (models.SymbolInfo, error) {
+	return a.SymbolInfoWithContext(context.Background(), symbol)
+}
+
+func (a *Api) SymbolInfoWithContext(ctx context.Context, symbol string) (models.SymbolInfo, error) {
+	if err := a.loadSymbols(ctx); err != nil {
+		return models.SymbolInfo{}, err
+	}
+
+	a.symMu.RLock()
+	info, ok := a.symbols[strings.ToUpper(symbol)]
+	a.symMu.RUnlock()
+
+	if !ok {
+		return info, fmt.Errorf("%w: %s not found", ErrInvalidSymbol, symbol)
+	}
+	return info, nil
+}
+
+// SymbolRegistry returns a copy of the symbol registry indexed by upper case
+// symbol.
+func (a *Api) SymbolRegistry() (map[string]models.SymbolInfo, error) {
+	return a.SymbolRegistryWithContext(context.Background())
+}
+
+func (a *Api) SymbolRegistryWithContext(ctx context.Context) (map[string]models.SymbolInfo, error) {
+	if err := a.loadSymbols(ctx); err != nil {
+		return nil, err
+	}
+
+	a.symMu.RLock()
+	defer a.symMu.RUnlock()
+
+	registry := make(map[string]models.SymbolInfo, len(a.symbols))
+	for symbol, info := range a.symbols {
+		registry[symbol] = info
+	}
+	return registry, nil
+}
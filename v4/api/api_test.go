@@ -0,0 +1,79 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/client"
+)
+
+// newMockApi returns an Api logged in to a fresh mock server, without the
+// client-side rate limiter and with short retry waits.
+func newMockApi(tb testing.TB, opts ...Options) (*Api, *mockserver.Server) {
+	tb.Helper()
+
+	srv, err := mockserver.New()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(srv.Close)
+
+	return mockApi(tb, srv, opts...), srv
+}
+
+// mockApi is newMockApi for a server started with its own options.
+func mockApi(tb testing.TB, srv *mockserver.Server, opts ...Options) *Api {
+	tb.Helper()
+
+	opts = append([]Options{
+		OptKey("key"),
+		OptSecret("secret"),
+		OptEndpoint(srv.URL()),
+		OptRateLimiter(nil),
+		OptRetryPolicy(client.RetryPolicy{RetryMax: 2, RetryWaitMin: time.Millisecond, RetryWaitMax: 5 * time.Millisecond}),
+		OptPlaceOrderRetry(2, 10*time.Millisecond),
+	}, opts...)
+	a, err := New(opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	if _, err := a.GetAccounts(); err != nil {
+		tb.Fatal(err)
+	}
+	return a
+}
+
+func BenchmarkTickers(b *testing.B) {
+	a, srv := newMockApi(b)
+	if err := srv.AddLiquidity("BTC-BRL", "sell", "150001", "1"); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := a.Tickers("BTC-BRL"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPlaceOrder includes CacheSetOrder, whose index grows with every
+// order placed, so compare runs with the same -benchtime.
+func BenchmarkPlaceOrder(b *testing.B) {
+	a, srv := newMockApi(b)
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000000000"); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		info := a.PlaceOrder(PoSymbol("BTC-BRL"), PoKind(BUY), PoType("limit"), PoPrice("100"), PoQty("0.001"))
+		if info.Error != nil {
+			b.Fatal(info.Error)
+		}
+	}
+}
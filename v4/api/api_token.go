@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/config"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/caller"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/ratelimit"
+)
+
+// tokenExpiryMargin renews the token slightly before its expiration so a
+// request never leaves with a token that expires while in flight.
+const tokenExpiryMargin = 30 * time.Second
+
+// cachedToken returns the token stored in cache and whether it is still usable.
+// A token without expiration is considered valid until the API rejects it.
+func (a *Api) cachedToken() (models.AuthoritionToken, bool) {
+	auth := models.AuthoritionToken{}
+
+	val, err := a.cache.GetKeyVal(config.AUTHORIZE.String())
+	if err != nil || len(val) == 0 {
+		return auth, false
+	}
+
+	if err := json.Unmarshal([]byte(val), &auth); err != nil {
+		return auth, false
+	}
+
+	if len(auth.AccessToken) == 0 {
+		return auth, false
+	}
+
+	if auth.Expiration > 0 {
+		expiration := time.Unix(int64(auth.Expiration), 0)
+		if time.Now().Add(tokenExpiryMargin).After(expiration) {
+			return auth, false
+		}
+	}
+
+	return auth, true
+}
+
+// ensureToken authorizes again when the cached token is missing or expired.
+// Concurrent callers wait on the same lock, so only the first one logs in and
+// the others reuse the token it stored.
+func (a *Api) ensureToken(ctx context.Context) error {
+	if _, ok := a.cachedToken(); ok {
+		return nil
+	}
+
+	a.authMu.Lock()
+	defer a.authMu.Unlock()
+
+	if _, ok := a.cachedToken(); ok {
+		return nil
+	}
+
+	if _, err := a.AuthorizationTokenWithContext(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// refreshToken forces a new authorization after the API rejected stale. When
+// another goroutine already replaced it, the new token is reused instead.
+func (a *Api) refreshToken(ctx context.Context, stale string) error {
+	a.authMu.Lock()
+	defer a.authMu.Unlock()
+
+	if auth, ok := a.cachedToken(); ok && auth.AccessToken != stale {
+		return nil
+	}
+
+	if _, err := a.AuthorizationTokenWithContext(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// doWithToken sends an authenticated request, renewing the token before it
+// expires and retrying once when the API answers 401. key is the
+// config.EndPoints name of the request, used for rate limiting.
+func (a *Api) doWithToken(ctx context.Context, key, method, endpoint string, payload []byte) (*http.Response, error) {
+	if err := a.ensureToken(ctx); err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("ensureToken")
+		}
+		return nil, err
+	}
+
+	ctx = ratelimit.WithKey(ctx, key)
+
+	auth, _ := a.cachedToken()
+	res, err := a.sendWithToken(ctx, key, method, endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusUnauthorized {
+		return res, nil
+	}
+	res.Body.Close()
+
+	if err := a.refreshToken(ctx, auth.AccessToken); err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("refreshToken")
+		}
+		return nil, err
+	}
+
+	return a.sendWithToken(ctx, key, method, endpoint, payload)
+}
+
+// nonIdempotent lists the endpoints the transport must never send twice: a
+// retry after a lost response could duplicate the order or the withdrawal.
+var nonIdempotent = map[string]bool{
+	"ORDER_PLACE":     true,
+	"WALLET_WITHDRAW": true,
+}
+
+func (a *Api) sendWithToken(ctx context.Context, key, method, endpoint string, payload []byte) (*http.Response, error) {
+	req, err := caller.RequestWithToken(method, endpoint, payload, a.cache)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("RequestWithToken")
+		}
+		return nil, err
+	}
+	req.NoRetry = nonIdempotent[key]
+
+	return a.rest.Do(ctx, req)
+}
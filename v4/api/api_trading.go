@@ -1,19 +1,19 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/shopspring/decimal"
 	"github.com/thiagozs/go-cache/v1/cache/drivers/kind"
 	"github.com/thiagozs/go-mbsdk/v4/config"
 	"github.com/thiagozs/go-mbsdk/v4/models"
-	"github.com/thiagozs/go-mbsdk/v4/pkg/caller"
 	"github.com/thiagozs/go-mbsdk/v4/pkg/replacer"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/utils"
 
 	"github.com/google/go-querystring/query"
 )
@@ -23,13 +23,14 @@ type OrdersParams func(o *OrdersPameters) error
 type PlaceOrdersParams func(o *PlaceOrdersPameters) error
 
 type PlaceOrdersPameters struct {
-	Symbol    string
-	Side      string
-	Price     string
-	Kind      Kind
-	Type      string
-	PriceStop string
-	Quantity  string
+	Symbol     string
+	Side       string
+	Price      string
+	Kind       Kind
+	Type       string
+	PriceStop  string
+	Quantity   string
+	ExternalID string
 }
 
 func PoSymbol(value string) PlaceOrdersParams {
@@ -81,6 +82,15 @@ func PoQty(value string) PlaceOrdersParams {
 	}
 }
 
+// PoExternalID tags the order with an id of the caller. When not given, a
+// random one is generated so the order can be reconciled after a lost answer.
+func PoExternalID(value string) PlaceOrdersParams {
+	return func(a *PlaceOrdersPameters) error {
+		a.ExternalID = value
+		return nil
+	}
+}
+
 type OrdersPameters struct {
 	HasExecutions string `url:"has_executions,omitempty"`
 	Side          string `url:"side,omitempty"`
@@ -141,26 +151,71 @@ func OrdCreatedTo(value string) OrdersParams {
 }
 
 func (a *Api) PlaceOrder(opts ...PlaceOrdersParams) models.CustomPlaceOrderInfo {
-	orderInfo := models.CustomPlaceOrderInfo{}
+	return a.PlaceOrderWithContext(context.Background(), opts...)
+}
+
+func (a *Api) PlaceOrderWithContext(ctx context.Context, opts ...PlaceOrdersParams) models.CustomPlaceOrderInfo {
+	result, err := a.SubmitOrderWithContext(ctx, opts...)
+
+	orderInfo := models.CustomPlaceOrderInfo{
+		StatusCode: result.StatusCode,
+		OrderID:    result.OrderID,
+		Response:   result.Response,
+		EndPoint:   result.EndPoint,
+		Error:      err,
+	}
+
+	if len(result.EndPoint) > 0 {
+		orderInfo.Payload = string(result.Payload.ToBytes())
+	}
+
+	return orderInfo
+}
+
+func (a *Api) SubmitOrder(opts ...PlaceOrdersParams) (models.PlaceOrderResult, error) {
+	return a.SubmitOrderWithContext(context.Background(), opts...)
+}
+
+func (a *Api) SubmitOrderWithContext(ctx context.Context, opts ...PlaceOrdersParams) (models.PlaceOrderResult, error) {
+	result := models.PlaceOrderResult{}
 	params := &PlaceOrdersPameters{}
-	errApi := models.ErrorApiResponse{}
 
 	for _, op := range opts {
 		err := op(params)
 		if err != nil {
-			return orderInfo
+			return result, err
+		}
+	}
+
+	if a.validateOrders {
+		rules, err := a.OrderRulesWithContext(ctx, params.Symbol)
+		if err != nil {
+			return result, err
+		}
+		if err := rules.Validate(params, a.autoRound); err != nil {
+			if a.cfg.Debug {
+				a.log.Error().Stack().Err(err).Msg("Validate")
+			}
+			return result, err
 		}
 	}
 
 	order := models.PlaceOrderPayload{Async: true, Type: params.Type}
 
-	price, _ := decimal.NewFromString(params.Price)
-	pricestop, _ := decimal.NewFromString(params.PriceStop)
+	price, err := utils.ParseDecimal(params.Price)
+	if err != nil {
+		return result, fmt.Errorf("invalid price %q: %w", params.Price, err)
+	}
 
-	cutPrice := strings.Split(price.String(), ".")
-	limitPrice, _ := strconv.ParseInt(cutPrice[0], 10, 64)
+	pricestop, err := utils.ParseDecimal(params.PriceStop)
+	if err != nil {
+		return result, fmt.Errorf("invalid stop price %q: %w", params.PriceStop, err)
+	}
 
-	cutPriceStop, _ := strconv.ParseInt(pricestop.String(), 10, 64)
+	qty, err := utils.ParseDecimal(params.Quantity)
+	if err != nil {
+		return result, fmt.Errorf("invalid quantity %q: %w", params.Quantity, err)
+	}
 
 	switch params.Kind {
 	case BUY:
@@ -169,98 +224,96 @@ func (a *Api) PlaceOrder(opts ...PlaceOrdersParams) models.CustomPlaceOrderInfo
 		order.Side = SELL.String()
 	case STOP_BUY:
 		order.Side = STOP_BUY.String()
-		order.StopPrice = int(cutPriceStop)
+		order.StopPrice = pricestop
 	case STOP_SELL:
 		order.Side = STOP_SELL.String()
-		order.StopPrice = int(cutPriceStop)
+		order.StopPrice = pricestop
+	}
+
+	if price.GreaterThan(decimal.Zero) {
+		order.LimitPrice = price
 	}
 
-	if price.GreaterThan(decimal.RequireFromString("0")) {
-		order.LimitPrice = int(limitPrice)
+	order.Qty = qty
+
+	order.ExternalID = params.ExternalID
+	if len(order.ExternalID) == 0 {
+		order.ExternalID, err = newExternalID()
+		if err != nil {
+			return result, err
+		}
 	}
 
-	order.Qty = params.Quantity
+	result.Payload = order
+	result.ExternalID = order.ExternalID
+
+	if a.paper != nil {
+		return a.paperSubmit(ctx, params.Symbol, order, result)
+	}
 
-	c, err := caller.ClientWithToken(http.MethodPost, a.cache)
+	accountID, err := a.accountID(ctx)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithToken")
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
 		}
-		orderInfo.Error = err
-		return orderInfo
+		return result, err
 	}
 
 	endpoint, err := replacer.Endpoint(replacer.OptKey("ORDER_PLACE"),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
 		replacer.OptSymbol(params.Symbol),
 		replacer.OptCache(a.cache),
 		replacer.OptLog(a.log),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
-		orderInfo.Error = err
-		return orderInfo
+		return result, err
 	}
 
-	orderInfo.EndPoint = endpoint
-	orderInfo.Payload = string(order.ToBytes())
+	result.EndPoint = endpoint
 
-	resp, err := c.PostWithResponse(endpoint, order.ToBytes())
+	status, bts, reconciled, err := a.sendOrder(ctx, params.Symbol, endpoint, order)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("PostWithResponse")
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("sendOrder")
 		}
-		orderInfo.Error = err
-		return orderInfo
+		return result, err
 	}
-	defer resp.Body.Close()
 
-	orderInfo.StatusCode = resp.StatusCode
+	result.StatusCode = status
+	result.Response = string(bts)
+	result.Reconciled = reconciled
 
-	bts, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ReadAll")
-		}
-		orderInfo.Error = err
-		return orderInfo
-	}
-
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Debug().
 			Str("endpoint", endpoint).
-			Int("status_code", resp.StatusCode).
+			Int("status_code", status).
+			Bool("reconciled", reconciled).
 			Str("body", string(bts)).
 			Msg("")
 	}
 
-	if resp.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal ErrorApi")
-			}
-			orderInfo.Error = err
-			return orderInfo
-		}
-		orderInfo.Error = fmt.Errorf("%s - %s", errApi.Code, errApi.Message)
-		orderInfo.StatusCode = resp.StatusCode
-		orderInfo.Response = string(bts)
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(orderInfo.Error).Msg("Return orderInfo Error")
+	if status >= 400 {
+		err := newAPIError(endpoint, status, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return orderInfo
+		return result, err
 	}
 
 	respOrder := models.PlaceOrderResponse{}
 	if err := json.Unmarshal(bts, &respOrder); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal PlaceOrderResponse")
 		}
-		orderInfo.Error = err
-		return orderInfo
+		return result, err
 	}
 
+	result.OrderID = respOrder.OrderID
+
 	if err := a.CacheSetOrder([]models.OrdersIndex{
 		{
 			Symbol: params.Symbol,
@@ -270,46 +323,51 @@ func (a *Api) PlaceOrder(opts ...PlaceOrdersParams) models.CustomPlaceOrderInfo
 			Type:   order.Type,
 		},
 	}); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Cache SetOrder")
 		}
-		orderInfo.Error = err
-		return orderInfo
+		return result, err
 	}
 
-	orderInfo.Response = string(respOrder.ToBytes())
-	orderInfo.OrderID = respOrder.OrderID
-
-	return orderInfo
+	return result, nil
 }
 
 func (a *Api) CancelOrder(symbol string, id string) error {
-	errApi := models.ErrorApiResponse{}
+	return a.CancelOrderWithContext(context.Background(), symbol, id)
+}
+
+func (a *Api) CancelOrderWithContext(ctx context.Context, symbol string, id string) error {
+
+	if a.paper != nil {
+		return paperError(a.paper.Cancel(ctx, symbol, id))
+	}
 
-	c, err := caller.ClientWithToken(http.MethodDelete, a.cache)
+	accountID, err := a.accountID(ctx)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithToken")
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
 		}
 		return err
 	}
 
 	endpoint, err := replacer.Endpoint(replacer.OptKey("ORDER_CANCEL"),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
 		replacer.OptSymbol(symbol),
 		replacer.OptCache(a.cache),
 		replacer.OptOrderId(id),
 		replacer.OptLog(a.log),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return err
 	}
 
-	res, err := c.DeleteWithResponse(endpoint, nil)
+	res, err := a.doWithToken(ctx, "ORDER_CANCEL", http.MethodDelete, endpoint, nil)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Delete")
 		}
 		return err
@@ -318,13 +376,13 @@ func (a *Api) CancelOrder(symbol string, id string) error {
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -333,19 +391,21 @@ func (a *Api) CancelOrder(symbol string, id string) error {
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return fmt.Errorf("%s - %s", errApi.Code, errApi.Message)
+		return err
 	}
 
 	return nil
 }
 
 func (a *Api) CancelAllCachedOrders(symbol string) error {
+	return a.CancelAllCachedOrdersWithContext(context.Background(), symbol)
+}
+
+func (a *Api) CancelAllCachedOrdersWithContext(ctx context.Context, symbol string) error {
 
 	if a.cache.GetDriver() == kind.GOCACHE {
 		return fmt.Errorf("sorry, this method is not supported for GOCACHE driver")
@@ -358,7 +418,7 @@ func (a *Api) CancelAllCachedOrders(symbol string) error {
 
 	ordersIndex := []models.OrdersIndex{}
 	if err := json.Unmarshal([]byte(val), &ordersIndex); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal OrderIndex")
 		}
 		return err
@@ -366,8 +426,8 @@ func (a *Api) CancelAllCachedOrders(symbol string) error {
 
 	for i, v := range ordersIndex {
 		if strings.EqualFold(v.Symbol, symbol) {
-			if err := a.CancelOrder(symbol, v.ID); err != nil {
-				if config.Config.Debug {
+			if err := a.CancelOrderWithContext(ctx, symbol, v.ID); err != nil {
+				if a.cfg.Debug {
 					a.log.Error().Stack().Err(err).Msg("CancelOrder")
 				}
 				return err
@@ -377,7 +437,7 @@ func (a *Api) CancelAllCachedOrders(symbol string) error {
 	}
 
 	if err := a.CacheSetOrder(ordersIndex); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("SetOrder")
 		}
 		return err
@@ -387,30 +447,41 @@ func (a *Api) CancelAllCachedOrders(symbol string) error {
 }
 
 func (a *Api) CancelAllOpenOrders(symbol string) error {
-	errApi := models.ErrorApiResponse{}
+	return a.CancelAllOpenOrdersWithContext(context.Background(), symbol)
+}
 
-	c, err := caller.ClientWithToken(http.MethodDelete, a.cache)
+func (a *Api) CancelAllOpenOrdersWithContext(ctx context.Context, symbol string) error {
+
+	if a.paper != nil {
+		_, err := a.paper.CancelAll(ctx, symbol)
+		return paperError(err)
+	}
+
+	accountID, err := a.accountID(ctx)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithToken")
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
 		}
 		return err
 	}
 
 	endpoint, err := replacer.Endpoint(replacer.OptKey("ORDER_CANCEL_ALL"),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
 		replacer.OptSymbol(symbol),
+		replacer.OptCache(a.cache),
 		replacer.OptLog(a.log),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return err
 	}
 
-	res, err := c.DeleteWithResponse(endpoint, nil)
+	res, err := a.doWithToken(ctx, "ORDER_CANCEL_ALL", http.MethodDelete, endpoint, nil)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Delete")
 		}
 		return err
@@ -419,13 +490,13 @@ func (a *Api) CancelAllOpenOrders(symbol string) error {
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -434,45 +505,58 @@ func (a *Api) CancelAllOpenOrders(symbol string) error {
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return fmt.Errorf("%s - %s", errApi.Code, errApi.Message)
+		return err
 	}
 
 	return nil
 }
 
-func (a *Api) GetOrder(symbol string) (models.GetOrderResponse, error) {
+func (a *Api) GetOrder(symbol, orderID string) (models.GetOrderResponse, error) {
+	return a.GetOrderWithContext(context.Background(), symbol, orderID)
+}
+
+func (a *Api) GetOrderWithContext(ctx context.Context, symbol, orderID string) (models.GetOrderResponse, error) {
 	order := models.GetOrderResponse{}
-	errApi := models.ErrorApiResponse{}
 
-	c, err := caller.ClientWithToken(http.MethodGet, a.cache)
+	if len(orderID) == 0 {
+		return order, fmt.Errorf("order id is required")
+	}
+
+	if a.paper != nil {
+		order, err := a.paper.Order(ctx, symbol, orderID)
+		return order, paperError(err)
+	}
+
+	accountID, err := a.accountID(ctx)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithToken")
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
 		}
 		return order, err
 	}
 
 	endpoint, err := replacer.Endpoint(replacer.OptKey("ORDER_GET"),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
 		replacer.OptSymbol(symbol),
 		replacer.OptCache(a.cache),
+		replacer.OptOrderId(orderID),
 		replacer.OptLog(a.log),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return order, err
 	}
 
-	res, err := c.GetWithResponse(endpoint)
+	res, err := a.doWithToken(ctx, "ORDER_GET", http.MethodGet, endpoint, nil)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
 		return order, err
@@ -481,13 +565,13 @@ func (a *Api) GetOrder(symbol string) (models.GetOrderResponse, error) {
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return order, err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -496,17 +580,15 @@ func (a *Api) GetOrder(symbol string) (models.GetOrderResponse, error) {
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return order, err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return order, fmt.Errorf("%s - %s", errApi.Code, errApi.Message)
+		return order, err
 	}
 
 	if err := json.Unmarshal(bts, &order); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal GetOrderResponse")
 		}
 		return order, err
@@ -516,8 +598,11 @@ func (a *Api) GetOrder(symbol string) (models.GetOrderResponse, error) {
 }
 
 func (a *Api) ListOrders(symbol string, opts ...OrdersParams) (models.ListOrderResponse, error) {
+	return a.ListOrdersWithContext(context.Background(), symbol, opts...)
+}
+
+func (a *Api) ListOrdersWithContext(ctx context.Context, symbol string, opts ...OrdersParams) (models.ListOrderResponse, error) {
 	order := models.ListOrderResponse{}
-	errApi := models.ErrorApiResponse{}
 
 	params := &OrdersPameters{}
 
@@ -528,31 +613,37 @@ func (a *Api) ListOrders(symbol string, opts ...OrdersParams) (models.ListOrderR
 		}
 	}
 
-	c, err := caller.ClientWithToken(http.MethodGet, a.cache)
+	if a.paper != nil {
+		return a.paperOrders(ctx, symbol, params)
+	}
+
+	v, _ := query.Values(params)
+	accountID, err := a.accountID(ctx)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithToken")
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
 		}
 		return order, err
 	}
 
-	v, _ := query.Values(params)
 	endpoint, err := replacer.Endpoint(replacer.OptKey("ORDER_LIST"),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
 		replacer.OptSymbol(symbol),
 		replacer.OptCache(a.cache),
 		replacer.OptLog(a.log),
 		replacer.OptParams(v.Encode()),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return order, err
 	}
 
-	res, err := c.GetWithResponse(endpoint)
+	res, err := a.doWithToken(ctx, "ORDER_LIST", http.MethodGet, endpoint, nil)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
 		return order, err
@@ -561,13 +652,13 @@ func (a *Api) ListOrders(symbol string, opts ...OrdersParams) (models.ListOrderR
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return order, err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -576,17 +667,15 @@ func (a *Api) ListOrders(symbol string, opts ...OrdersParams) (models.ListOrderR
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return order, err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return order, fmt.Errorf("%s - %s", errApi.Code, errApi.Message)
+		return order, err
 	}
 
 	if err := json.Unmarshal(bts, &order); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal ListOrderResponse")
 		}
 		return order, err
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"sync"
+
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// defaultBatchParallel is the number of concurrent requests of a batch call
+// when none is given.
+const defaultBatchParallel = 4
+
+func (a *Api) GetOrders(symbol string, orderIDs []string, parallel int) (map[string]models.GetOrderResponse, error) {
+	return a.GetOrdersWithContext(context.Background(), symbol, orderIDs, parallel)
+}
+
+// GetOrdersWithContext fetches the orders of symbol by id, at most parallel
+// at a time. The orders found are returned even when some fail; the failures
+// are reported together as a *BatchError, the ids not yet started when ctx
+// is done failing with its error. Repeated ids are fetched once.
+func (a *Api) GetOrdersWithContext(ctx context.Context, symbol string, orderIDs []string, parallel int) (map[string]models.GetOrderResponse, error) {
+	if parallel <= 0 {
+		parallel = defaultBatchParallel
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		orders = make(map[string]models.GetOrderResponse, len(orderIDs))
+		failed = map[string]error{}
+	)
+
+	seen := map[string]bool{}
+	sem := make(chan struct{}, parallel)
+ids:
+	for i, id := range orderIDs {
+		if seen[id] {
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			// The ids not started fail with the context.
+			mu.Lock()
+			for _, id := range orderIDs[i:] {
+				if !seen[id] {
+					seen[id] = true
+					failed[id] = ctx.Err()
+				}
+			}
+			mu.Unlock()
+			break ids
+		}
+		seen[id] = true
+
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			order, err := a.GetOrderWithContext(ctx, symbol, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[id] = err
+				return
+			}
+			orders[id] = order
+		}(id)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		err := &BatchError{Total: len(orders) + len(failed), Errors: failed}
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("GetOrders")
+		}
+		return orders, err
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+)
+
+// placeBuys places n resting buys and returns their ids.
+func placeBuys(t *testing.T, a *Api, srv *mockserver.Server, n int) []string {
+	t.Helper()
+
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000"); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{}
+	for i := 0; i < n; i++ {
+		id, _, err := placeBuy(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestGetOrdersPartialFailure(t *testing.T) {
+	a, srv := newMockApi(t)
+	ids := placeBuys(t, a, srv, 2)
+
+	orders, err := a.GetOrders("BTC-BRL", []string{ids[0], "missing", ids[1]}, 2)
+
+	batch := &BatchError{}
+	if !errors.As(err, &batch) {
+		t.Fatalf("expected a BatchError, got %v", err)
+	}
+	if batch.Total != 3 || len(batch.Errors) != 1 || !errors.Is(batch.Errors["missing"], ErrOrderNotFound) {
+		t.Errorf("unexpected batch error %v", batch)
+	}
+	if !errors.Is(err, ErrOrderNotFound) {
+		t.Error("errors.Is does not match the failure of one id")
+	}
+
+	// The orders found come with the error.
+	if len(orders) != 2 {
+		t.Fatalf("%d orders, want 2", len(orders))
+	}
+	for _, id := range ids {
+		if orders[id].ID != id {
+			t.Errorf("order %s missing from %v", id, orders)
+		}
+	}
+}
+
+func TestGetOrdersDuplicates(t *testing.T) {
+	a, srv := newMockApi(t)
+	ids := placeBuys(t, a, srv, 2)
+
+	orders, err := a.GetOrders("BTC-BRL", []string{ids[0], ids[0], ids[1], ids[0], ids[1]}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 {
+		t.Errorf("%d orders, want 2", len(orders))
+	}
+	if calls := srv.Calls("ORDER_GET"); calls != 2 {
+		t.Errorf("%d requests, want one per id", calls)
+	}
+
+	// A repeated failing id is reported once.
+	_, err = a.GetOrders("BTC-BRL", []string{"missing", ids[0], "missing"}, 2)
+	batch := &BatchError{}
+	if !errors.As(err, &batch) || batch.Total != 2 || len(batch.Errors) != 1 {
+		t.Errorf("expected 1 of 2 failed, got %v", err)
+	}
+}
+
+func TestGetOrdersCancel(t *testing.T) {
+	a, srv := newMockApi(t)
+	ids := placeBuys(t, a, srv, 4)
+	srv.Fail("ORDER_GET", mockserver.Failure{Delay: 5 * time.Second, Times: 10})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	orders, err := a.GetOrdersWithContext(ctx, "BTC-BRL", ids, 1)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("returned after %s, not when cancelled", elapsed)
+	}
+
+	batch := &BatchError{}
+	if !errors.As(err, &batch) {
+		t.Fatalf("expected a BatchError, got %v", err)
+	}
+	// The id in flight and the ones waiting for a slot all fail.
+	if batch.Total != 4 || len(batch.Errors) != 4 || len(orders) != 0 {
+		t.Errorf("%d orders and %v, want 4 of 4 failed", len(orders), batch)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls := srv.Calls("ORDER_GET"); calls > 1 {
+		t.Errorf("%d requests started, want at most 1", calls)
+	}
+}
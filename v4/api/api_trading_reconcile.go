@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// newExternalID returns a random id used to find an order again when the
+// answer to its placement is lost.
+func newExternalID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// outcomeUnknown reports whether a placement answered with status may still
+// have created the order.
+func outcomeUnknown(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// sendOrder posts order once per attempt. When an attempt fails without a
+// definitive answer, the order is searched by its external id, after waiting
+// for the order list to catch up, and sent again only when a last search right
+// before the resend still does not find it, so a retry never duplicates it.
+// reconciled is set when the order was found instead of created by the last
+// attempt; the body then carries the PlaceOrderResponse of the order found.
+func (a *Api) sendOrder(ctx context.Context, symbol, endpoint string, order models.PlaceOrderPayload) (status int, body []byte, reconciled bool, err error) {
+	since := time.Now().Add(-time.Minute)
+
+	for attempt := 0; ; attempt++ {
+		status, body, err = a.postOrder(ctx, endpoint, order)
+		if err == nil && !outcomeUnknown(status) {
+			return status, body, false, nil
+		}
+
+		cause := err
+		if cause == nil {
+			cause = newAPIError(endpoint, status, body)
+		}
+		unknown := fmt.Errorf("%w: external id %s: %v", ErrOrderStateUnknown, order.ExternalID, cause)
+
+		if a.cfg.Debug {
+			a.log.Warn().Err(cause).
+				Str("external_id", order.ExternalID).
+				Int("attempt", attempt).
+				Msg("order placement without answer, reconciling")
+		}
+
+		// The first search covers every attempt, the second one only those
+		// followed by a resend.
+		searches := 1
+		if attempt < a.placeRetries {
+			searches = 2
+		}
+
+		for i := 0; i < searches; i++ {
+			select {
+			case <-ctx.Done():
+				return status, body, false, unknown
+			case <-time.After(a.placeRetryWait):
+			}
+
+			found, ok, ferr := a.FindOrderByExternalIDWithContext(ctx, symbol, order.ExternalID, since)
+			if ferr != nil {
+				if a.cfg.Debug {
+					a.log.Error().Stack().Err(ferr).Msg("FindOrderByExternalID")
+				}
+				return status, body, false, unknown
+			}
+
+			if ok {
+				resp := models.PlaceOrderResponse{OrderID: found.ID}
+				return http.StatusOK, resp.ToBytes(), true, nil
+			}
+		}
+
+		if attempt >= a.placeRetries {
+			return status, body, false, err
+		}
+	}
+}
+
+func (a *Api) postOrder(ctx context.Context, endpoint string, order models.PlaceOrderPayload) (int, []byte, error) {
+	res, err := a.doWithToken(ctx, "ORDER_PLACE", http.MethodPost, endpoint, order.ToBytes())
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("PostWithResponse")
+		}
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	bts, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("ReadAll")
+		}
+		return res.StatusCode, nil, err
+	}
+
+	return res.StatusCode, bts, nil
+}
+
+func (a *Api) FindOrderByExternalID(symbol, externalID string, since time.Time) (models.GetOrderResponse, bool, error) {
+	return a.FindOrderByExternalIDWithContext(context.Background(), symbol, externalID, since)
+}
+
+// FindOrderByExternalIDWithContext searches the orders of symbol created
+// after since for the one tagged with externalID.
+func (a *Api) FindOrderByExternalIDWithContext(ctx context.Context, symbol, externalID string, since time.Time) (models.GetOrderResponse, bool, error) {
+	if len(externalID) == 0 {
+		return models.GetOrderResponse{}, false, fmt.Errorf("external id is required")
+	}
+
+	orders, err := a.ListOrdersWithContext(ctx, symbol, OrdCreatedFrom(strconv.FormatInt(since.Unix(), 10)))
+	if err != nil {
+		return models.GetOrderResponse{}, false, err
+	}
+
+	for _, order := range orders {
+		if order.ExternalID == externalID {
+			return order, true, nil
+		}
+	}
+
+	return models.GetOrderResponse{}, false, nil
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/ratelimit"
+)
+
+func placeBuy(a *Api) (string, bool, error) {
+	result, err := a.SubmitOrder(PoSymbol("BTC-BRL"), PoKind(BUY), PoType("limit"), PoPrice("100"), PoQty("0.5"))
+	return result.OrderID, result.Reconciled, err
+}
+
+func assertOneOrder(t *testing.T, a *Api, id string) {
+	t.Helper()
+
+	orders, err := a.ListOrders("BTC-BRL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 {
+		t.Fatalf("%d orders on the server, want 1", len(orders))
+	}
+	if orders[0].ID != id {
+		t.Errorf("order %s returned, %s on the server", id, orders[0].ID)
+	}
+}
+
+func TestSendOrderReconciles(t *testing.T) {
+	tests := []struct {
+		name    string
+		failure mockserver.Failure
+		opts    []Options
+		wait    time.Duration
+	}{
+		{
+			name:    "dropped connection",
+			failure: mockserver.Failure{Process: true, Drop: true},
+		},
+		{
+			name:    "503",
+			failure: mockserver.Failure{Process: true, Status: http.StatusServiceUnavailable, Code: "API|UNAVAILABLE"},
+		},
+		{
+			name:    "429 with Retry-After",
+			failure: mockserver.Failure{Process: true, Status: http.StatusTooManyRequests, Code: "API|RATE_LIMIT", RetryAfter: "1"},
+			opts:    []Options{OptRateLimits(map[ratelimit.Group]ratelimit.Limit{ratelimit.TRADING: {Rate: 100, Burst: 100}})},
+			wait:    time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, srv := newMockApi(t, tt.opts...)
+			if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000"); err != nil {
+				t.Fatal(err)
+			}
+			srv.Fail("ORDER_PLACE", tt.failure)
+
+			start := time.Now()
+			id, reconciled, err := placeBuy(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reconciled {
+				t.Error("Reconciled not set")
+			}
+			if elapsed := time.Since(start); elapsed < tt.wait {
+				t.Errorf("lookup after %s, before the Retry-After of %s", elapsed, tt.wait)
+			}
+			if calls := srv.Calls("ORDER_PLACE"); calls != 1 {
+				t.Errorf("order sent %d times, want 1", calls)
+			}
+			assertOneOrder(t, a, id)
+		})
+	}
+}
+
+func TestSendOrderListLag(t *testing.T) {
+	srv, err := mockserver.New(mockserver.OptListLag(60 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	// The first search runs before the order is listed, the one before the
+	// resend finds it.
+	a := mockApi(t, srv, OptPlaceOrderRetry(2, 40*time.Millisecond))
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000"); err != nil {
+		t.Fatal(err)
+	}
+	srv.Fail("ORDER_PLACE", mockserver.Failure{Process: true, Drop: true})
+
+	id, reconciled, err := placeBuy(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reconciled {
+		t.Error("Reconciled not set")
+	}
+	if calls := srv.Calls("ORDER_PLACE"); calls != 1 {
+		t.Errorf("order sent %d times, want 1", calls)
+	}
+	if calls := srv.Calls("ORDER_LIST"); calls != 2 {
+		t.Errorf("%d searches, want 2", calls)
+	}
+	assertOneOrder(t, a, id)
+}
+
+func TestSendOrderRetriesWhenNotCreated(t *testing.T) {
+	a, srv := newMockApi(t)
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000"); err != nil {
+		t.Fatal(err)
+	}
+	srv.Fail("ORDER_PLACE", mockserver.Failure{Status: http.StatusServiceUnavailable, Code: "API|UNAVAILABLE"})
+
+	id, reconciled, err := placeBuy(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reconciled {
+		t.Error("Reconciled set for an order created by the retry")
+	}
+	if calls := srv.Calls("ORDER_PLACE"); calls != 2 {
+		t.Errorf("order sent %d times, want 2", calls)
+	}
+	assertOneOrder(t, a, id)
+}
+
+func TestSendOrderLookupFails(t *testing.T) {
+	a, srv := newMockApi(t)
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000"); err != nil {
+		t.Fatal(err)
+	}
+	srv.Fail("ORDER_PLACE", mockserver.Failure{Process: true, Drop: true})
+	srv.Fail("ORDER_LIST", mockserver.Failure{Status: http.StatusInternalServerError, Code: "API|INTERNAL", Times: 3})
+
+	_, reconciled, err := placeBuy(a)
+	if !errors.Is(err, ErrOrderStateUnknown) {
+		t.Fatalf("expected ErrOrderStateUnknown, got %v", err)
+	}
+	if reconciled {
+		t.Error("Reconciled set without a lookup")
+	}
+	if calls := srv.Calls("ORDER_PLACE"); calls != 1 {
+		t.Errorf("order sent %d times, want 1", calls)
+	}
+
+	// The order exists: sending it again would have duplicated it.
+	orders, err := a.ListOrders("BTC-BRL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 {
+		t.Errorf("%d orders on the server, want 1", len(orders))
+	}
+}
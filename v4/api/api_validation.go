@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/utils"
+)
+
+// ErrInvalidOrder is wrapped by every error returned by the order validator.
+var ErrInvalidOrder = errors.New("invalid order")
+
+// OrderRules are the trading constraints of a symbol checked before an order
+// is sent. Zero values disable the corresponding check.
+type OrderRules struct {
+	Symbol      string
+	TickSize    decimal.Decimal
+	QtyStep     decimal.Decimal
+	MinQty      decimal.Decimal
+	MinNotional decimal.Decimal
+}
+
+// NewOrderRules derives the rules of symbol from the Symbols response, using
+// minmovement / pricescale as tick size. The response has no quantity step,
+// so the quantity is only checked against one set by OptOrderRules or
+// SetOrderRules.
+func NewOrderRules(symbols models.SymbolsResponse, symbol string) (OrderRules, error) {
+	index, err := symbols.BySymbol()
+	if err != nil {
+		return OrderRules{Symbol: symbol}, err
+	}
+
+	info, ok := index[strings.ToUpper(symbol)]
+	if !ok {
+		return OrderRules{Symbol: symbol}, fmt.Errorf("symbol %s not found", symbol)
+	}
+
+	return rulesOf(info), nil
+}
+
+func rulesOf(info models.SymbolInfo) OrderRules {
+	return OrderRules{Symbol: info.Symbol, TickSize: info.TickSize}
+}
+
+// merge fills the zero fields of r with the values from other.
+func (r OrderRules) merge(other OrderRules) OrderRules {
+	if len(r.Symbol) == 0 {
+		r.Symbol = other.Symbol
+	}
+	if r.TickSize.IsZero() {
+		r.TickSize = other.TickSize
+	}
+	if r.QtyStep.IsZero() {
+		r.QtyStep = other.QtyStep
+	}
+	if r.MinQty.IsZero() {
+		r.MinQty = other.MinQty
+	}
+	if r.MinNotional.IsZero() {
+		r.MinNotional = other.MinNotional
+	}
+	return r
+}
+
+// Validate checks the order parameters against the rules. With autoRound the
+// price is moved to the tick grid (down for buys, up for sells) and the
+// quantity is truncated to the quantity step, updating params in place.
+func (r OrderRules) Validate(params *PlaceOrdersPameters, autoRound bool) error {
+	if len(params.Symbol) == 0 {
+		return fmt.Errorf("%w: symbol is required", ErrInvalidOrder)
+	}
+
+	if len(r.Symbol) > 0 && !strings.EqualFold(r.Symbol, params.Symbol) {
+		return fmt.Errorf("%w: rules of %s used for %s", ErrInvalidOrder, r.Symbol, params.Symbol)
+	}
+
+	if params.Kind < BUY || params.Kind > STOP_SELL {
+		return fmt.Errorf("%w: unknown kind %d", ErrInvalidOrder, params.Kind)
+	}
+
+	if len(params.Side) > 0 && !strings.EqualFold(params.Side, params.Kind.String()) {
+		return fmt.Errorf("%w: side %q does not match kind %s", ErrInvalidOrder, params.Side, params.Kind)
+	}
+
+	price, err := utils.ParseDecimal(params.Price)
+	if err != nil {
+		return fmt.Errorf("%w: price %q: %v", ErrInvalidOrder, params.Price, err)
+	}
+
+	stop, err := utils.ParseDecimal(params.PriceStop)
+	if err != nil {
+		return fmt.Errorf("%w: stop price %q: %v", ErrInvalidOrder, params.PriceStop, err)
+	}
+
+	qty, err := utils.ParseDecimal(params.Quantity)
+	if err != nil {
+		return fmt.Errorf("%w: quantity %q: %v", ErrInvalidOrder, params.Quantity, err)
+	}
+
+	if price.IsNegative() || stop.IsNegative() {
+		return fmt.Errorf("%w: prices must not be negative", ErrInvalidOrder)
+	}
+
+	if !qty.IsPositive() {
+		return fmt.Errorf("%w: quantity is required", ErrInvalidOrder)
+	}
+
+	stopKind := params.Kind == STOP_BUY || params.Kind == STOP_SELL
+
+	switch strings.ToLower(params.Type) {
+	case "limit":
+		if !price.IsPositive() {
+			return fmt.Errorf("%w: limit order requires a price", ErrInvalidOrder)
+		}
+		if stop.IsPositive() || stopKind {
+			return fmt.Errorf("%w: limit order does not take a stop price, use type stoplimit", ErrInvalidOrder)
+		}
+	case "market":
+		if price.IsPositive() || stop.IsPositive() {
+			return fmt.Errorf("%w: market order does not take a price", ErrInvalidOrder)
+		}
+		if stopKind {
+			return fmt.Errorf("%w: market order does not take kind %d", ErrInvalidOrder, params.Kind)
+		}
+	case "stoplimit":
+		if !price.IsPositive() || !stop.IsPositive() {
+			return fmt.Errorf("%w: stoplimit order requires price and stop price", ErrInvalidOrder)
+		}
+		if !stopKind {
+			return fmt.Errorf("%w: stoplimit order requires kind STOP_BUY or STOP_SELL", ErrInvalidOrder)
+		}
+	case "":
+		return fmt.Errorf("%w: type is required", ErrInvalidOrder)
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidOrder, params.Type)
+	}
+
+	buy := params.Kind == BUY || params.Kind == STOP_BUY
+
+	if r.TickSize.IsPositive() {
+		for _, p := range []struct {
+			name  string
+			value *decimal.Decimal
+			raw   *string
+		}{{"price", &price, &params.Price}, {"stop price", &stop, &params.PriceStop}} {
+			if p.value.IsZero() || p.value.Mod(r.TickSize).IsZero() {
+				continue
+			}
+			if !autoRound {
+				return fmt.Errorf("%w: %s %s is not a multiple of tick size %s",
+					ErrInvalidOrder, p.name, p.value, r.TickSize)
+			}
+			*p.value = roundToStep(*p.value, r.TickSize, !buy)
+			if !p.value.IsPositive() {
+				return fmt.Errorf("%w: %s rounds to zero with tick size %s", ErrInvalidOrder, p.name, r.TickSize)
+			}
+			*p.raw = p.value.String()
+		}
+	}
+
+	if r.QtyStep.IsPositive() && !qty.Mod(r.QtyStep).IsZero() {
+		if !autoRound {
+			return fmt.Errorf("%w: quantity %s is not a multiple of step %s",
+				ErrInvalidOrder, qty, r.QtyStep)
+		}
+		qty = roundToStep(qty, r.QtyStep, false)
+		params.Quantity = qty.String()
+	}
+
+	if !qty.IsPositive() {
+		return fmt.Errorf("%w: quantity rounds to zero with step %s", ErrInvalidOrder, r.QtyStep)
+	}
+
+	if r.MinQty.IsPositive() && qty.LessThan(r.MinQty) {
+		return fmt.Errorf("%w: quantity %s is below the minimum %s", ErrInvalidOrder, qty, r.MinQty)
+	}
+
+	if r.MinNotional.IsPositive() && price.IsPositive() {
+		if notional := price.Mul(qty); notional.LessThan(r.MinNotional) {
+			return fmt.Errorf("%w: notional %s is below the minimum %s",
+				ErrInvalidOrder, notional, r.MinNotional)
+		}
+	}
+
+	return nil
+}
+
+// roundToStep moves value to a multiple of step, rounding up when up is true
+// and down otherwise.
+func roundToStep(value, step decimal.Decimal, up bool) decimal.Decimal {
+	steps := value.Div(step)
+	if up {
+		return steps.Ceil().Mul(step)
+	}
+	return steps.Floor().Mul(step)
+}
+
+// SetOrderRules overrides the rules of a symbol. Zero fields keep the values
+// derived from the Symbols endpoint.
+func (a *Api) SetOrderRules(rules OrderRules) {
+	a.rulesMu.Lock()
+	defer a.rulesMu.Unlock()
+	a.ruleOverrides[strings.ToUpper(rules.Symbol)] = rules
+}
+
+// OrderRules returns the rules of symbol, derived from the symbol registry
+// and merged with the overrides.
+func (a *Api) OrderRules(symbol string) (OrderRules, error) {
+	return a.OrderRulesWithContext(context.Background(), symbol)
+}
+
+func (a *Api) OrderRulesWithContext(ctx context.Context, symbol string) (OrderRules, error) {
+	info, err := a.SymbolInfoWithContext(ctx, symbol)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("SymbolInfo")
+		}
+		return OrderRules{}, err
+	}
+
+	a.rulesMu.RLock()
+	override := a.ruleOverrides[strings.ToUpper(symbol)]
+	a.rulesMu.RUnlock()
+
+	return override.merge(rulesOf(info)), nil
+}
+
+// ValidateOrder runs the pre-flight checks of PlaceOrder without sending it.
+func (a *Api) ValidateOrder(opts ...PlaceOrdersParams) error {
+	return a.ValidateOrderWithContext(context.Background(), opts...)
+}
+
+func (a *Api) ValidateOrderWithContext(ctx context.Context, opts ...PlaceOrdersParams) error {
+	params := &PlaceOrdersPameters{}
+	for _, op := range opts {
+		if err := op(params); err != nil {
+			return err
+		}
+	}
+
+	rules, err := a.OrderRulesWithContext(ctx, params.Symbol)
+	if err != nil {
+		return err
+	}
+
+	return rules.Validate(params, false)
+}
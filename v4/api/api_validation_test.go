@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+func TestValidateAutoRound(t *testing.T) {
+	rules := OrderRules{
+		Symbol:   "BTC-BRL",
+		TickSize: decimal.RequireFromString("0.01"),
+		QtyStep:  decimal.RequireFromString("0.0001"),
+	}
+
+	tests := []struct {
+		name   string
+		params PlaceOrdersPameters
+		price  string
+		stop   string
+		qty    string
+		err    bool
+	}{
+		{
+			name:   "buy rounds down",
+			params: PlaceOrdersPameters{Symbol: "BTC-BRL", Kind: BUY, Type: "limit", Price: "100.019", Quantity: "0.00019"},
+			price:  "100.01",
+			qty:    "0.0001",
+		},
+		{
+			name:   "sell rounds up",
+			params: PlaceOrdersPameters{Symbol: "BTC-BRL", Kind: SELL, Type: "limit", Price: "100.011", Quantity: "1"},
+			price:  "100.02",
+			qty:    "1",
+		},
+		{
+			name:   "buy price below tick",
+			params: PlaceOrdersPameters{Symbol: "BTC-BRL", Kind: BUY, Type: "limit", Price: "0.009", Quantity: "1"},
+			err:    true,
+		},
+		{
+			name:   "stop price below tick",
+			params: PlaceOrdersPameters{Symbol: "BTC-BRL", Kind: STOP_BUY, Type: "stoplimit", Price: "10", PriceStop: "0.004", Quantity: "1"},
+			err:    true,
+		},
+		{
+			name:   "quantity below step",
+			params: PlaceOrdersPameters{Symbol: "BTC-BRL", Kind: BUY, Type: "limit", Price: "10", Quantity: "0.00001"},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			err := rules.Validate(&params, true)
+			if tt.err {
+				if !errors.Is(err, ErrInvalidOrder) {
+					t.Fatalf("expected ErrInvalidOrder, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if params.Price != tt.price || params.Quantity != tt.qty {
+				t.Errorf("got price %s qty %s, want %s %s", params.Price, params.Quantity, tt.price, tt.qty)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutAutoRound(t *testing.T) {
+	rules := OrderRules{Symbol: "BTC-BRL", TickSize: decimal.RequireFromString("0.01")}
+	params := PlaceOrdersPameters{Symbol: "BTC-BRL", Kind: BUY, Type: "limit", Price: "100.019", Quantity: "1"}
+
+	if err := rules.Validate(&params, false); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("expected ErrInvalidOrder, got %v", err)
+	}
+	if params.Price != "100.019" {
+		t.Errorf("price changed to %s", params.Price)
+	}
+}
+
+func TestQtyStep(t *testing.T) {
+	symbols := models.SymbolsResponse{
+		Symbol:      []string{"BTC-BRL", "ETH-BRL"},
+		Minmovement: []string{"1", "1"},
+		Pricescale:  []int{100, 100},
+	}
+
+	// The response has no step, so the quantity is not checked.
+	rules, err := NewOrderRules(symbols, "BTC-BRL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rules.QtyStep.IsZero() {
+		t.Errorf("BTC-BRL step %s, want none", rules.QtyStep)
+	}
+	params := PlaceOrdersPameters{Symbol: "BTC-BRL", Kind: BUY, Type: "limit", Price: "100", Quantity: "0.123456789012"}
+	if err := rules.Validate(&params, false); err != nil {
+		t.Errorf("unexpected error without a step: %v", err)
+	}
+
+	// Unless the Api is given one.
+	a, srv := newMockApi(t, OptOrderRules(OrderRules{Symbol: "ETH-BRL", QtyStep: decimal.RequireFromString("0.001")}))
+	srv.SetSymbols(symbols)
+
+	for symbol, want := range map[string]string{"BTC-BRL": "0", "ETH-BRL": "0.001"} {
+		rules, err := a.OrderRules(symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rules.QtyStep.String() != want {
+			t.Errorf("%s step %s, want %s", symbol, rules.QtyStep, want)
+		}
+		if rules.TickSize.String() != "0.01" {
+			t.Errorf("%s tick size %s, want 0.01", symbol, rules.TickSize)
+		}
+	}
+
+	a.SetOrderRules(OrderRules{Symbol: "btc-brl", QtyStep: decimal.RequireFromString("0.0001")})
+	rules, err = a.OrderRules("BTC-BRL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.QtyStep.String() != "0.0001" || rules.TickSize.String() != "0.01" {
+		t.Errorf("BTC-BRL step %s tick size %s, want 0.0001 and 0.01", rules.QtyStep, rules.TickSize)
+	}
+	err = a.ValidateOrder(PoSymbol("BTC-BRL"), PoKind(BUY), PoType("limit"), PoPrice("100"), PoQty("0.00015"))
+	if !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("expected ErrInvalidOrder off the step, got %v", err)
+	}
+}
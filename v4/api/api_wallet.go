@@ -1,16 +1,16 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 
 	"github.com/google/go-querystring/query"
-	"github.com/thiagozs/go-mbsdk/v4/config"
 	"github.com/thiagozs/go-mbsdk/v4/models"
-	"github.com/thiagozs/go-mbsdk/v4/pkg/caller"
 	"github.com/thiagozs/go-mbsdk/v4/pkg/replacer"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/utils"
 )
 
 type WalletDepOptions func(c *WalletDepParameters) error
@@ -60,12 +60,14 @@ func WalletDepSymbol(symbol string) WalletDepOptions {
 }
 
 type WalletCoinParameters struct {
-	AccountRef  int    `url:"account_ref,omitempty"`
-	Address     string `url:"address,omitempty"`
-	Description string `url:"description,omitempty"`
-	Quantity    string `url:"quantity,omitempty"`
-	Symbol      string `url:"symbol,omitempty"`
-	TxFee       string `url:"tx_fee,omitempty"`
+	AccountRef     int    `url:"account_ref,omitempty"`
+	Address        string `url:"address,omitempty"`
+	DestinationTag string `url:"destination_tag,omitempty"`
+	Network        string `url:"network,omitempty"`
+	Description    string `url:"description,omitempty"`
+	Quantity       string `url:"quantity,omitempty"`
+	Symbol         string `url:"symbol,omitempty"`
+	TxFee          string `url:"tx_fee,omitempty"`
 }
 
 func WalletCoinAccRef(accRef int) WalletCoinOptions {
@@ -82,6 +84,22 @@ func WalletCoinAddr(addr string) WalletCoinOptions {
 	}
 }
 
+// WalletCoinTag sets the destination tag or memo required by some coins.
+func WalletCoinTag(tag string) WalletCoinOptions {
+	return func(c *WalletCoinParameters) error {
+		c.DestinationTag = tag
+		return nil
+	}
+}
+
+// WalletCoinNetwork picks the network of coins listed on several chains.
+func WalletCoinNetwork(network string) WalletCoinOptions {
+	return func(c *WalletCoinParameters) error {
+		c.Network = network
+		return nil
+	}
+}
+
 func WalletCoinDesc(desc string) WalletCoinOptions {
 	return func(c *WalletCoinParameters) error {
 		c.Description = desc
@@ -111,9 +129,12 @@ func WalletCoinTxFee(tx_fee string) WalletCoinOptions {
 }
 
 func (a *Api) WalletGetDeposit(opts ...WalletDepOptions) (models.WalletGetDepositsResponse, error) {
+	return a.WalletGetDepositWithContext(context.Background(), opts...)
+}
+
+func (a *Api) WalletGetDepositWithContext(ctx context.Context, opts ...WalletDepOptions) (models.WalletGetDepositsResponse, error) {
 	deposits := models.WalletGetDepositsResponse{}
 	params := &WalletDepParameters{}
-	errApi := models.ErrorApiResponse{}
 
 	for _, op := range opts {
 		err := op(params)
@@ -126,49 +147,58 @@ func (a *Api) WalletGetDeposit(opts ...WalletDepOptions) (models.WalletGetDeposi
 		return deposits, fmt.Errorf("symbol is required")
 	}
 
-	c, err := caller.ClientWithToken(http.MethodGet, a.cache)
+	err := a.walletGet(ctx, "WALLET_DEPOSIT", params, &deposits)
+	return deposits, err
+}
+
+// walletGet calls the wallet list endpoint key with params and decodes the
+// answer into out.
+func (a *Api) walletGet(ctx context.Context, key string, params *WalletDepParameters, out interface{}) error {
+	v, _ := query.Values(params)
+	accountID, err := a.accountID(ctx)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithToken")
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
 		}
-		return deposits, err
+		return err
 	}
 
-	v, _ := query.Values(params)
 	endpoint, err := replacer.Endpoint(
-		replacer.OptKey("WALLET_DEPOSIT"),
+		replacer.OptKey(key),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
 		replacer.OptSymbol(params.Symbol),
 		replacer.OptCache(a.cache),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
-		return deposits, err
+		return err
 	}
 
-	if (&WalletDepParameters{}) != params {
+	if (WalletDepParameters{}) != *params {
 		endpoint = fmt.Sprintf("%s?%s", endpoint, v.Encode())
 	}
 
-	res, err := c.GetWithResponse(endpoint)
+	res, err := a.doWithToken(ctx, key, http.MethodGet, endpoint, nil)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
-		return deposits, err
+		return err
 	}
 	defer res.Body.Close()
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
-		return deposits, err
+		return err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -177,53 +207,56 @@ func (a *Api) WalletGetDeposit(opts ...WalletDepOptions) (models.WalletGetDeposi
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return deposits, err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return deposits, fmt.Errorf("%s - %s", errApi.Code, errApi.Message)
+		return err
 	}
 
-	if err := json.Unmarshal(bts, &deposits); err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("Json Unmarshal deposits")
+	if err := json.Unmarshal(bts, out); err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msgf("Json Unmarshal %s", key)
 		}
-		return deposits, err
+		return err
 	}
 
-	return deposits, nil
+	return nil
 }
 
-func (a *Api) WalletGetWithdrawCoin(symbol, withdrawId string) (models.WalletGetDepositsResponse, error) {
-	withdrawcoin := models.WalletGetDepositsResponse{}
-	errApi := models.ErrorApiResponse{}
+func (a *Api) WalletGetWithdrawCoin(symbol, withdrawId string) (models.WalletWithdrawCoinResponse, error) {
+	return a.WalletGetWithdrawCoinWithContext(context.Background(), symbol, withdrawId)
+}
 
-	c, err := caller.ClientWithToken(http.MethodGet, a.cache)
+func (a *Api) WalletGetWithdrawCoinWithContext(ctx context.Context, symbol, withdrawId string) (models.WalletWithdrawCoinResponse, error) {
+	withdrawcoin := models.WalletWithdrawCoinResponse{}
+
+	accountID, err := a.accountID(ctx)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithToken")
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
 		}
 		return withdrawcoin, err
 	}
 
 	endpoint, err := replacer.Endpoint(
 		replacer.OptKey("WALLET_GETWITHDRAW"),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
 		replacer.OptSymbol(symbol),
 		replacer.OptWithDrawId(withdrawId),
 		replacer.OptCache(a.cache),
 	)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Replacer")
 		}
 		return withdrawcoin, err
 	}
 
-	res, err := c.GetWithResponse(endpoint)
+	res, err := a.doWithToken(ctx, "WALLET_GETWITHDRAW", http.MethodGet, endpoint, nil)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Get")
 		}
 		return withdrawcoin, err
@@ -232,13 +265,13 @@ func (a *Api) WalletGetWithdrawCoin(symbol, withdrawId string) (models.WalletGet
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return withdrawcoin, err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -247,17 +280,15 @@ func (a *Api) WalletGetWithdrawCoin(symbol, withdrawId string) (models.WalletGet
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return withdrawcoin, err
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return withdrawcoin, fmt.Errorf("%s - %s", errApi.Code, errApi.Message)
+		return withdrawcoin, err
 	}
 
 	if err := json.Unmarshal(bts, &withdrawcoin); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal withdrawcoin")
 		}
 		return withdrawcoin, err
@@ -267,53 +298,29 @@ func (a *Api) WalletGetWithdrawCoin(symbol, withdrawId string) (models.WalletGet
 }
 
 func (a *Api) WalletWithdrawCoin(opts ...WalletCoinOptions) (models.WalletWithdrawCoinResponse, error) {
-	withdrawcoin := models.WalletWithdrawCoinResponse{}
-	params := &WalletCoinParameters{}
-	errApi := models.ErrorApiResponse{}
-
-	for _, op := range opts {
-		err := op(params)
-		if err != nil {
-			return withdrawcoin, err
-		}
-	}
+	return a.WalletWithdrawCoinWithContext(context.Background(), opts...)
+}
 
-	if params.Symbol == "" {
-		return withdrawcoin, fmt.Errorf("symbol is required")
-	}
+// WalletWithdrawCoinWithContext sends a coin withdrawal after checking it
+// against the address book, the maximum amount and the daily limit.
+func (a *Api) WalletWithdrawCoinWithContext(ctx context.Context, opts ...WalletCoinOptions) (models.WalletWithdrawCoinResponse, error) {
+	withdrawcoin := models.WalletWithdrawCoinResponse{}
 
-	c, err := caller.ClientWithToken(http.MethodPost, a.cache)
+	endpoint, wcp, err := a.prepareWithdraw(ctx, opts...)
 	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("ClientWithToken")
-		}
 		return withdrawcoin, err
 	}
 
-	endpoint, err := replacer.Endpoint(
-		replacer.OptKey("WALLET_GETDRAW"),
-		replacer.OptSymbol(params.Symbol),
-		replacer.OptCache(a.cache),
-	)
-	if err != nil {
-		if config.Config.Debug {
-			a.log.Error().Stack().Err(err).Msg("Replacer")
+	if err := a.checkWithdraw(wcp, true); err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("checkWithdraw")
 		}
 		return withdrawcoin, err
 	}
 
-	wcp := models.WalletWithdrawCoinPayload{
-		AccountRef:  params.AccountRef,
-		Address:     params.Address,
-		Description: params.Description,
-		Quantity:    params.Quantity,
-		Symbol:      params.Symbol,
-		TxFee:       params.TxFee,
-	}
-
-	res, err := c.PostWithResponse(endpoint, wcp.ToBytes())
+	res, err := a.doWithToken(ctx, "WALLET_WITHDRAW", http.MethodPost, endpoint, wcp.ToBytes())
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("PostWithResponse")
 		}
 		return withdrawcoin, err
@@ -322,13 +329,13 @@ func (a *Api) WalletWithdrawCoin(opts ...WalletCoinOptions) (models.WalletWithdr
 
 	bts, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("ReadAll")
 		}
 		return withdrawcoin, err
 	}
 
-	if config.Config.Debug {
+	if a.cfg.Debug {
 		a.log.Info().
 			Str("endpoint", endpoint).
 			Int("status_code", res.StatusCode).
@@ -337,17 +344,18 @@ func (a *Api) WalletWithdrawCoin(opts ...WalletCoinOptions) (models.WalletWithdr
 	}
 
 	if res.StatusCode >= 400 {
-		if err := json.Unmarshal(bts, &errApi); err != nil {
-			if config.Config.Debug {
-				a.log.Error().Stack().Err(err).Msg("Json Unmarshal errApi")
-			}
-			return withdrawcoin, err
+		if res.StatusCode < 500 {
+			a.releaseWithdraw(wcp)
+		}
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
 		}
-		return withdrawcoin, fmt.Errorf("%s - %s", errApi.Code, errApi.Message)
+		return withdrawcoin, err
 	}
 
 	if err := json.Unmarshal(bts, &withdrawcoin); err != nil {
-		if config.Config.Debug {
+		if a.cfg.Debug {
 			a.log.Error().Stack().Err(err).Msg("Json Unmarshal withdrawcoin")
 		}
 		return withdrawcoin, err
@@ -355,3 +363,95 @@ func (a *Api) WalletWithdrawCoin(opts ...WalletCoinOptions) (models.WalletWithdr
 
 	return withdrawcoin, nil
 }
+
+func (a *Api) WalletWithdrawCoinDryRun(opts ...WalletCoinOptions) (models.WithdrawPreview, error) {
+	return a.WalletWithdrawCoinDryRunWithContext(context.Background(), opts...)
+}
+
+// WalletWithdrawCoinDryRunWithContext runs the checks of WalletWithdrawCoin
+// and returns the request it would send, without sending it.
+func (a *Api) WalletWithdrawCoinDryRunWithContext(ctx context.Context, opts ...WalletCoinOptions) (models.WithdrawPreview, error) {
+	endpoint, wcp, err := a.prepareWithdraw(ctx, opts...)
+	if err != nil {
+		return models.WithdrawPreview{}, err
+	}
+
+	if err := a.checkWithdraw(wcp, false); err != nil {
+		return models.WithdrawPreview{}, err
+	}
+
+	return models.WithdrawPreview{
+		Method:   http.MethodPost,
+		Endpoint: endpoint,
+		Payload:  wcp.ToBytes(),
+	}, nil
+}
+
+func (a *Api) prepareWithdraw(ctx context.Context, opts ...WalletCoinOptions) (string, models.WalletWithdrawCoinPayload, error) {
+	wcp := models.WalletWithdrawCoinPayload{}
+	params := &WalletCoinParameters{}
+
+	for _, op := range opts {
+		err := op(params)
+		if err != nil {
+			return "", wcp, err
+		}
+	}
+
+	if params.Symbol == "" {
+		return "", wcp, fmt.Errorf("symbol is required")
+	}
+
+	if params.Address == "" {
+		return "", wcp, fmt.Errorf("address is required")
+	}
+
+	quantity, err := utils.ParseDecimal(params.Quantity)
+	if err != nil {
+		return "", wcp, fmt.Errorf("invalid quantity %q: %w", params.Quantity, err)
+	}
+
+	if !quantity.IsPositive() {
+		return "", wcp, fmt.Errorf("quantity is required")
+	}
+
+	txFee, err := utils.ParseDecimal(params.TxFee)
+	if err != nil {
+		return "", wcp, fmt.Errorf("invalid tx_fee %q: %w", params.TxFee, err)
+	}
+
+	accountID, err := a.accountID(ctx)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
+		}
+		return "", wcp, err
+	}
+
+	endpoint, err := replacer.Endpoint(
+		replacer.OptKey("WALLET_WITHDRAW"),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
+		replacer.OptSymbol(params.Symbol),
+		replacer.OptCache(a.cache),
+	)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("Replacer")
+		}
+		return "", wcp, err
+	}
+
+	wcp = models.WalletWithdrawCoinPayload{
+		AccountRef:     params.AccountRef,
+		Address:        params.Address,
+		DestinationTag: params.DestinationTag,
+		Network:        params.Network,
+		Description:    params.Description,
+		Quantity:       quantity,
+		Symbol:         params.Symbol,
+		TxFee:          txFee,
+	}
+
+	return endpoint, wcp, nil
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+type DepositsOptions func(d *DepositsParameters) error
+
+type DepositsParameters struct {
+	Symbol    string
+	From      time.Time
+	To        time.Time
+	PageSize  int
+	Max       int
+	Pace      time.Duration
+	RetryWait time.Duration
+}
+
+// DepSymbol walks the deposits of one coin, e.g. BTC. Without it every base
+// currency of the symbol registry is walked.
+func DepSymbol(symbol string) DepositsOptions {
+	return func(d *DepositsParameters) error {
+		d.Symbol = strings.ToUpper(symbol)
+		return nil
+	}
+}
+
+func DepFrom(from time.Time) DepositsOptions {
+	return func(d *DepositsParameters) error {
+		d.From = from
+		return nil
+	}
+}
+
+func DepTo(to time.Time) DepositsOptions {
+	return func(d *DepositsParameters) error {
+		d.To = to
+		return nil
+	}
+}
+
+// DepPageSize sets the deposits asked per request, 50 by default.
+func DepPageSize(size int) DepositsOptions {
+	return func(d *DepositsParameters) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid page size %d", size)
+		}
+		d.PageSize = size
+		return nil
+	}
+}
+
+// DepMax stops the walk after max deposits.
+func DepMax(max int) DepositsOptions {
+	return func(d *DepositsParameters) error {
+		if max <= 0 {
+			return fmt.Errorf("invalid max %d", max)
+		}
+		d.Max = max
+		return nil
+	}
+}
+
+// DepPace waits at least pace between two page requests, on top of the rate
+// limiter.
+func DepPace(pace time.Duration) DepositsOptions {
+	return func(d *DepositsParameters) error {
+		if pace < 0 {
+			return fmt.Errorf("invalid pace %s", pace)
+		}
+		d.Pace = pace
+		return nil
+	}
+}
+
+// DepRetryWait sets the wait before asking again a page answered with 429,
+// doubled on every attempt, 1s by default. A rate limiter also holds the
+// request until the Retry-After of the answer.
+func DepRetryWait(wait time.Duration) DepositsOptions {
+	return func(d *DepositsParameters) error {
+		if wait < 0 {
+			return fmt.Errorf("invalid retry wait %s", wait)
+		}
+		d.RetryWait = wait
+		return nil
+	}
+}
+
+// depositPageRetries is how many times a page answered with 429 is asked
+// again, once the retries of the transport are exhausted.
+const depositPageRetries = 3
+
+// DepositIterator walks the deposit history page by page:
+//
+//	it := a.Deposits(api.DepSymbol("BTC"))
+//	for it.Next(ctx) {
+//		fmt.Println(it.Deposit())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type DepositIterator struct {
+	a       *Api
+	params  *DepositsParameters
+	symbols []string
+	loaded  bool
+
+	page    int
+	buf     []models.Deposit
+	current models.Deposit
+	count   int
+	last    time.Time
+	stopped bool
+	err     error
+}
+
+// Deposits returns an iterator over the deposits matching opts. Invalid
+// options are reported by Err after the first Next.
+func (a *Api) Deposits(opts ...DepositsOptions) *DepositIterator {
+	it := &DepositIterator{a: a, params: &DepositsParameters{PageSize: 50, RetryWait: time.Second}}
+
+	for _, op := range opts {
+		if err := op(it.params); err != nil {
+			it.err = err
+			return it
+		}
+	}
+
+	if !it.params.From.IsZero() && !it.params.To.IsZero() && it.params.To.Before(it.params.From) {
+		it.err = fmt.Errorf("parameters 'to' is before 'from'")
+	}
+
+	return it
+}
+
+// Next advances to the next deposit, fetching a page when needed. It returns
+// false at the end, after Stop, or on error.
+func (it *DepositIterator) Next(ctx context.Context) bool {
+	if it.err != nil || it.stopped {
+		return false
+	}
+
+	if it.params.Max > 0 && it.count >= it.params.Max {
+		return false
+	}
+
+	if !it.loaded {
+		if err := it.loadSymbols(ctx); err != nil {
+			it.err = err
+			return false
+		}
+		it.loaded = true
+	}
+
+	for len(it.buf) == 0 {
+		if len(it.symbols) == 0 {
+			return false
+		}
+
+		if err := it.fetch(ctx); err != nil {
+			it.err = err
+			return false
+		}
+	}
+
+	it.current, it.buf = it.buf[0], it.buf[1:]
+	it.count++
+	return true
+}
+
+func (it *DepositIterator) loadSymbols(ctx context.Context) error {
+	if len(it.params.Symbol) > 0 {
+		it.symbols = []string{it.params.Symbol}
+		return nil
+	}
+
+	registry, err := it.a.SymbolRegistryWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, info := range registry {
+		coin := strings.ToUpper(info.BaseCurrency)
+		if len(coin) > 0 && !seen[coin] {
+			seen[coin] = true
+			it.symbols = append(it.symbols, coin)
+		}
+	}
+	sort.Strings(it.symbols)
+	return nil
+}
+
+// fetch loads the next page of the current symbol, moving to the next symbol
+// after a short page.
+func (it *DepositIterator) fetch(ctx context.Context) error {
+	if wait := it.params.Pace - time.Since(it.last); it.params.Pace > 0 && wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	it.page++
+	opts := []WalletDepOptions{
+		WalletDepSymbol(it.symbols[0]),
+		WalletDepPage(strconv.Itoa(it.page)),
+		WalletDepLimit(strconv.Itoa(it.params.PageSize)),
+	}
+	if !it.params.From.IsZero() {
+		opts = append(opts, WalletDepFrom(strconv.FormatInt(it.params.From.Unix(), 10)))
+	}
+	if !it.params.To.IsZero() {
+		opts = append(opts, WalletDepTo(strconv.FormatInt(it.params.To.Unix(), 10)))
+	}
+
+	var (
+		deposits models.WalletGetDepositsResponse
+		err      error
+	)
+	for attempt := 0; ; attempt++ {
+		deposits, err = it.a.WalletGetDepositWithContext(ctx, opts...)
+		if !errors.Is(err, ErrRateLimited) || attempt == depositPageRetries {
+			break
+		}
+
+		timer := time.NewTimer(it.params.RetryWait << attempt)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	it.last = time.Now()
+	if err != nil {
+		return fmt.Errorf("deposits of %s, page %d: %w", it.symbols[0], it.page, err)
+	}
+
+	it.buf = deposits
+	if len(deposits) < it.params.PageSize {
+		it.symbols = it.symbols[1:]
+		it.page = 0
+	}
+	return nil
+}
+
+// Deposit returns the deposit Next moved to.
+func (it *DepositIterator) Deposit() models.Deposit {
+	return it.current
+}
+
+// Err returns the error that ended the iteration, if any.
+func (it *DepositIterator) Err() error {
+	return it.err
+}
+
+// Stop ends the iteration early; Next returns false afterwards.
+func (it *DepositIterator) Stop() {
+	it.stopped = true
+}
+
+func (a *Api) AllDeposits(opts ...DepositsOptions) ([]models.Deposit, error) {
+	return a.AllDepositsWithContext(context.Background(), opts...)
+}
+
+// AllDepositsWithContext collects every deposit matching opts. On error the
+// deposits collected so far are returned with it.
+func (a *Api) AllDepositsWithContext(ctx context.Context, opts ...DepositsOptions) ([]models.Deposit, error) {
+	deposits := []models.Deposit{}
+
+	it := a.Deposits(opts...)
+	for it.Next(ctx) {
+		deposits = append(deposits, it.Deposit())
+	}
+
+	if err := it.Err(); err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("AllDeposits")
+		}
+		return deposits, err
+	}
+
+	return deposits, nil
+}
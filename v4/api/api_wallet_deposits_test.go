@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+var depositsStart = time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// addDeposits adds n confirmed deposits of symbol, one minute apart from
+// depositsStart, of amounts 1 to n.
+func addDeposits(t *testing.T, srv *mockserver.Server, symbol string, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		d := mockserver.Deposit{
+			Amount:    strconv.Itoa(i + 1),
+			CreatedAt: depositsStart.Add(time.Duration(i) * time.Minute),
+			Status:    "confirmed",
+		}
+		if err := srv.AddDeposit(mockserver.DefaultAccountID, symbol, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertDeposits(t *testing.T, got []models.Deposit, want ...string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%d deposits, want %d: %+v", len(got), len(want), got)
+	}
+	for i, d := range got {
+		if d.Coin+" "+d.Amount.String() != want[i] {
+			t.Errorf("deposit %d is %s %s, want %s", i, d.Coin, d.Amount, want[i])
+		}
+	}
+}
+
+func TestDepositsPages(t *testing.T) {
+	a, srv := newMockApi(t)
+	addDeposits(t, srv, "BTC", 5)
+	addDeposits(t, srv, "ETH", 4)
+
+	// Every base currency of the registry, BTC then ETH.
+	deposits, err := a.AllDeposits(DepPageSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeposits(t, deposits, "BTC 1", "BTC 2", "BTC 3", "BTC 4", "BTC 5", "ETH 1", "ETH 2", "ETH 3", "ETH 4")
+
+	// BTC ends on a short page, ETH on an empty one.
+	if calls := srv.Calls("WALLET_DEPOSIT"); calls != 6 {
+		t.Errorf("%d requests, want 6", calls)
+	}
+
+	deposits, err = a.AllDeposits(DepSymbol("eth"), DepPageSize(3),
+		DepFrom(depositsStart.Add(time.Minute)), DepTo(depositsStart.Add(2*time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeposits(t, deposits, "ETH 2", "ETH 3")
+}
+
+func TestDepositsMaxAndStop(t *testing.T) {
+	a, srv := newMockApi(t)
+	addDeposits(t, srv, "BTC", 5)
+
+	deposits, err := a.AllDeposits(DepSymbol("BTC"), DepPageSize(2), DepMax(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeposits(t, deposits, "BTC 1", "BTC 2", "BTC 3")
+	if calls := srv.Calls("WALLET_DEPOSIT"); calls != 2 {
+		t.Errorf("%d requests, want 2", calls)
+	}
+
+	it := a.Deposits(DepSymbol("BTC"), DepPageSize(2))
+	ctx := context.Background()
+	if !it.Next(ctx) {
+		t.Fatalf("no deposit: %v", it.Err())
+	}
+	it.Stop()
+	if it.Next(ctx) {
+		t.Error("Next went on after Stop")
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if calls := srv.Calls("WALLET_DEPOSIT"); calls != 3 {
+		t.Errorf("%d requests, want 3", calls)
+	}
+}
+
+func TestDepositsInvalidOptions(t *testing.T) {
+	a, srv := newMockApi(t)
+
+	for name, opts := range map[string][]DepositsOptions{
+		"to before from": {DepFrom(depositsStart), DepTo(depositsStart.Add(-time.Minute))},
+		"page size":      {DepPageSize(0)},
+		"max":            {DepMax(-1)},
+		"pace":           {DepPace(-time.Second)},
+		"retry wait":     {DepRetryWait(-time.Second)},
+	} {
+		it := a.Deposits(opts...)
+		if it.Next(context.Background()) {
+			t.Errorf("%s: Next returned a deposit", name)
+		}
+		if it.Err() == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if calls := srv.Calls("WALLET_DEPOSIT"); calls != 0 {
+		t.Errorf("%d requests, want 0", calls)
+	}
+}
+
+func TestDepositsRateLimited(t *testing.T) {
+	a, srv := newMockApi(t)
+	addDeposits(t, srv, "BTC", 1)
+
+	// Three requests give up in the transport, the fourth one gets through
+	// once the page is asked again.
+	srv.Fail("WALLET_DEPOSIT", mockserver.Failure{Status: http.StatusTooManyRequests, Times: 3})
+
+	start := time.Now()
+	deposits, err := a.AllDeposits(DepSymbol("BTC"), DepRetryWait(30*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeposits(t, deposits, "BTC 1")
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("page asked again after %s, want 30ms", elapsed)
+	}
+
+	// The waits double until the retries run out.
+	srv.Fail("WALLET_DEPOSIT", mockserver.Failure{Status: http.StatusTooManyRequests, Times: 100})
+	calls := srv.Calls("WALLET_DEPOSIT")
+	start = time.Now()
+	_, err = a.AllDeposits(DepSymbol("BTC"), DepRetryWait(10*time.Millisecond))
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("gave up after %s, want 10 + 20 + 40ms", elapsed)
+	}
+	if n := srv.Calls("WALLET_DEPOSIT") - calls; n != 3*(depositPageRetries+1) {
+		t.Errorf("%d requests, want %d", n, 3*(depositPageRetries+1))
+	}
+
+	// The wait ends with the context.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start = time.Now()
+	_, err = a.AllDepositsWithContext(ctx, DepSymbol("BTC"), DepRetryWait(time.Minute))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("returned after %s, not when cancelled", elapsed)
+	}
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/replacer"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/utils"
+)
+
+// fiatSymbol is the only fiat currency of the exchange.
+const fiatSymbol = "BRL"
+
+type WalletFiatOptions func(c *WalletFiatParameters) error
+
+type WalletFiatParameters struct {
+	AccountRef  int
+	Description string
+	Quantity    string
+}
+
+// WalletFiatAccRef sets the bank account or PIX key receiving the withdrawal,
+// by the id the exchange gave it when it was registered.
+func WalletFiatAccRef(accRef int) WalletFiatOptions {
+	return func(c *WalletFiatParameters) error {
+		c.AccountRef = accRef
+		return nil
+	}
+}
+
+func WalletFiatDesc(desc string) WalletFiatOptions {
+	return func(c *WalletFiatParameters) error {
+		c.Description = desc
+		return nil
+	}
+}
+
+func WalletFiatQty(quantity string) WalletFiatOptions {
+	return func(c *WalletFiatParameters) error {
+		c.Quantity = quantity
+		return nil
+	}
+}
+
+func (a *Api) WalletGetWithdraws(opts ...WalletDepOptions) (models.WalletWithdrawListResponse, error) {
+	return a.WalletGetWithdrawsWithContext(context.Background(), opts...)
+}
+
+// WalletGetWithdrawsWithContext lists the withdrawals of a coin or of BRL,
+// filtered and paginated like the deposits.
+func (a *Api) WalletGetWithdrawsWithContext(ctx context.Context, opts ...WalletDepOptions) (models.WalletWithdrawListResponse, error) {
+	withdrawals := models.WalletWithdrawListResponse{}
+	params := &WalletDepParameters{}
+
+	for _, op := range opts {
+		err := op(params)
+		if err != nil {
+			return withdrawals, err
+		}
+	}
+
+	if params.Symbol == "" {
+		return withdrawals, fmt.Errorf("symbol is required")
+	}
+
+	err := a.walletGet(ctx, "WALLET_LISTWITHDRAW", params, &withdrawals)
+	return withdrawals, err
+}
+
+func (a *Api) WalletWithdrawFiat(opts ...WalletFiatOptions) (models.WalletWithdrawCoinResponse, error) {
+	return a.WalletWithdrawFiatWithContext(context.Background(), opts...)
+}
+
+// WalletWithdrawFiatWithContext sends a BRL withdrawal to a registered bank
+// account or PIX key, after the same guards as WalletWithdrawCoin. In the
+// address book the destination is the BRL entry whose Address is the
+// account_ref.
+func (a *Api) WalletWithdrawFiatWithContext(ctx context.Context, opts ...WalletFiatOptions) (models.WalletWithdrawCoinResponse, error) {
+	withdrawal := models.WalletWithdrawCoinResponse{}
+
+	endpoint, wfp, err := a.prepareFiatWithdraw(ctx, opts...)
+	if err != nil {
+		return withdrawal, err
+	}
+
+	if err := a.checkWithdraw(fiatGuard(wfp), true); err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("checkWithdraw")
+		}
+		return withdrawal, err
+	}
+
+	res, err := a.doWithToken(ctx, "WALLET_WITHDRAW", http.MethodPost, endpoint, wfp.ToBytes())
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("PostWithResponse")
+		}
+		return withdrawal, err
+	}
+	defer res.Body.Close()
+
+	bts, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("ReadAll")
+		}
+		return withdrawal, err
+	}
+
+	if a.cfg.Debug {
+		a.log.Info().
+			Str("endpoint", endpoint).
+			Int("status_code", res.StatusCode).
+			Str("body", string(bts)).
+			Msg("")
+	}
+
+	if res.StatusCode >= 400 {
+		if res.StatusCode < 500 {
+			a.releaseWithdraw(fiatGuard(wfp))
+		}
+		err := newAPIError(endpoint, res.StatusCode, bts)
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("APIError")
+		}
+		return withdrawal, err
+	}
+
+	if err := json.Unmarshal(bts, &withdrawal); err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("Json Unmarshal withdrawal")
+		}
+		return withdrawal, err
+	}
+
+	return withdrawal, nil
+}
+
+func (a *Api) WalletWithdrawFiatDryRun(opts ...WalletFiatOptions) (models.WithdrawPreview, error) {
+	return a.WalletWithdrawFiatDryRunWithContext(context.Background(), opts...)
+}
+
+// WalletWithdrawFiatDryRunWithContext runs the checks of WalletWithdrawFiat
+// and returns the request it would send, without sending it.
+func (a *Api) WalletWithdrawFiatDryRunWithContext(ctx context.Context, opts ...WalletFiatOptions) (models.WithdrawPreview, error) {
+	endpoint, wfp, err := a.prepareFiatWithdraw(ctx, opts...)
+	if err != nil {
+		return models.WithdrawPreview{}, err
+	}
+
+	if err := a.checkWithdraw(fiatGuard(wfp), false); err != nil {
+		return models.WithdrawPreview{}, err
+	}
+
+	return models.WithdrawPreview{
+		Method:   http.MethodPost,
+		Endpoint: endpoint,
+		Payload:  wfp.ToBytes(),
+	}, nil
+}
+
+func (a *Api) prepareFiatWithdraw(ctx context.Context, opts ...WalletFiatOptions) (string, models.WalletWithdrawFiatPayload, error) {
+	wfp := models.WalletWithdrawFiatPayload{}
+	params := &WalletFiatParameters{}
+
+	for _, op := range opts {
+		err := op(params)
+		if err != nil {
+			return "", wfp, err
+		}
+	}
+
+	if params.AccountRef <= 0 {
+		return "", wfp, fmt.Errorf("account_ref is required")
+	}
+
+	quantity, err := utils.ParseDecimal(params.Quantity)
+	if err != nil {
+		return "", wfp, fmt.Errorf("invalid quantity %q: %w", params.Quantity, err)
+	}
+
+	if !quantity.IsPositive() {
+		return "", wfp, fmt.Errorf("quantity is required")
+	}
+
+	accountID, err := a.accountID(ctx)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("accountID")
+		}
+		return "", wfp, err
+	}
+
+	endpoint, err := replacer.Endpoint(
+		replacer.OptKey("WALLET_WITHDRAW"),
+		replacer.OptConfig(a.cfg),
+		replacer.OptAccountId(accountID),
+		replacer.OptSymbol(fiatSymbol),
+		replacer.OptCache(a.cache),
+	)
+	if err != nil {
+		if a.cfg.Debug {
+			a.log.Error().Stack().Err(err).Msg("Replacer")
+		}
+		return "", wfp, err
+	}
+
+	wfp = models.WalletWithdrawFiatPayload{
+		AccountRef:  params.AccountRef,
+		Description: params.Description,
+		Quantity:    quantity,
+		Symbol:      fiatSymbol,
+	}
+
+	return endpoint, wfp, nil
+}
+
+// fiatGuard presents a BRL withdrawal to the withdrawal guards, the account
+// reference standing for the address.
+func fiatGuard(p models.WalletWithdrawFiatPayload) models.WalletWithdrawCoinPayload {
+	return models.WalletWithdrawCoinPayload{
+		Address:  strconv.Itoa(p.AccountRef),
+		Quantity: p.Quantity,
+		Symbol:   p.Symbol,
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+func TestWithdrawFiat(t *testing.T) {
+	a, srv := newMockApi(t)
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000"); err != nil {
+		t.Fatal(err)
+	}
+	ref := srv.AddBankAccount(mockserver.DefaultAccountID)
+
+	wd, err := a.WalletWithdrawFiat(WalletFiatAccRef(ref), WalletFiatQty("250.50"), WalletFiatDesc("treasury"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDecimal(t, "BRL after withdrawal", balanceOf(t, a, "BRL").Available, "749.5")
+
+	got, err := a.WalletGetWithdrawCoin("BRL", strconv.Itoa(wd.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.WithdrawStatus() != models.WithdrawPending {
+		t.Errorf("withdrawal is %s, want pending", got.WithdrawStatus())
+	}
+
+	withdrawals, err := a.WalletGetWithdraws(WalletDepSymbol("BRL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withdrawals) != 1 || withdrawals[0].ID != wd.ID {
+		t.Errorf("listed %+v, want withdrawal %d", withdrawals, wd.ID)
+	}
+
+	// The exchange refuses accounts that are not registered.
+	_, err = a.WalletWithdrawFiat(WalletFiatAccRef(ref+1), WalletFiatQty("10"))
+	apiErr := &APIError{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "WALLET|INVALID_ACCOUNT_REF" {
+		t.Errorf("expected an invalid account_ref, got %v", err)
+	}
+
+	_, err = a.WalletWithdrawFiat(WalletFiatAccRef(ref), WalletFiatQty("5000"))
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("expected ErrInsufficientBalance, got %v", err)
+	}
+	assertDecimal(t, "BRL after refused withdrawals", balanceOf(t, a, "BRL").Available, "749.5")
+}
+
+func TestWithdrawFiatValidation(t *testing.T) {
+	a, srv := newMockApi(t)
+	ref := srv.AddBankAccount(mockserver.DefaultAccountID)
+
+	tests := []struct {
+		name string
+		opts []WalletFiatOptions
+		want string
+	}{
+		{"no account", []WalletFiatOptions{WalletFiatQty("10")}, "account_ref is required"},
+		{"no quantity", []WalletFiatOptions{WalletFiatAccRef(ref)}, "quantity is required"},
+		{"zero quantity", []WalletFiatOptions{WalletFiatAccRef(ref), WalletFiatQty("0")}, "quantity is required"},
+		{"bad quantity", []WalletFiatOptions{WalletFiatAccRef(ref), WalletFiatQty("ten")}, "invalid quantity"},
+	}
+
+	for _, tt := range tests {
+		if _, err := a.WalletWithdrawFiat(tt.opts...); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.want, err)
+		}
+		if _, err := a.WalletWithdrawFiatDryRun(tt.opts...); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s dry run: expected %q, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	if calls := srv.Calls("WALLET_WITHDRAW"); calls != 0 {
+		t.Errorf("%d withdrawals sent, want 0", calls)
+	}
+}
+
+func TestWithdrawFiatDryRun(t *testing.T) {
+	a, srv := newMockApi(t)
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000"); err != nil {
+		t.Fatal(err)
+	}
+	ref := srv.AddBankAccount(mockserver.DefaultAccountID)
+
+	preview, err := a.WalletWithdrawFiatDryRun(WalletFiatAccRef(ref), WalletFiatQty("100"), WalletFiatDesc("treasury"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Method != http.MethodPost {
+		t.Errorf("method %s, want POST", preview.Method)
+	}
+	if want := "/accounts/" + mockserver.DefaultAccountID + "/wallet/BRL/withdraw"; !strings.HasSuffix(preview.Endpoint, want) {
+		t.Errorf("endpoint %s, want one ending in %s", preview.Endpoint, want)
+	}
+
+	payload := models.WalletWithdrawFiatPayload{}
+	if err := json.Unmarshal(preview.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.AccountRef != ref || payload.Symbol != "BRL" || payload.Description != "treasury" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	assertDecimal(t, "payload quantity", payload.Quantity, "100")
+
+	if calls := srv.Calls("WALLET_WITHDRAW"); calls != 0 {
+		t.Errorf("%d withdrawals sent, want 0", calls)
+	}
+	assertDecimal(t, "BRL after dry run", balanceOf(t, a, "BRL").Available, "1000")
+}
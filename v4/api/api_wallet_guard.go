@@ -0,0 +1,138 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// ErrWithdrawBlocked is wrapped by the errors of the local withdrawal guards:
+// address book, maximum amount and daily limit.
+var ErrWithdrawBlocked = errors.New("withdrawal blocked")
+
+// WithdrawAddress is an allowed destination of the withdrawal address book.
+// Network and Tag must match the withdrawal exactly, empty meaning not sent.
+// BRL entries hold the bank account id, the account_ref, as Address.
+type WithdrawAddress struct {
+	Symbol  string
+	Network string
+	Address string
+	Tag     string
+	Label   string
+}
+
+func (w WithdrawAddress) key() string {
+	return strings.Join([]string{strings.ToUpper(w.Symbol), strings.ToLower(w.Network), w.Address, w.Tag}, "|")
+}
+
+type withdrawRecord struct {
+	at       time.Time
+	quantity decimal.Decimal
+}
+
+// AddWithdrawAddress allows withdrawals to addr. Once an address is added,
+// or OptWithdrawAddressBook is used, withdrawals to other addresses fail.
+func (a *Api) AddWithdrawAddress(addr WithdrawAddress) {
+	a.wdMu.Lock()
+	defer a.wdMu.Unlock()
+
+	if a.wdBook == nil {
+		a.wdBook = make(map[string]WithdrawAddress)
+	}
+	addr.Symbol = strings.ToUpper(addr.Symbol)
+	a.wdBook[addr.key()] = addr
+}
+
+// RemoveWithdrawAddress forbids withdrawals to addr. The address book stays
+// enforced even when empty.
+func (a *Api) RemoveWithdrawAddress(addr WithdrawAddress) {
+	a.wdMu.Lock()
+	defer a.wdMu.Unlock()
+	delete(a.wdBook, addr.key())
+}
+
+// WithdrawAddresses lists the address book, or nil when it is not enforced.
+func (a *Api) WithdrawAddresses() []WithdrawAddress {
+	a.wdMu.Lock()
+	defer a.wdMu.Unlock()
+
+	if a.wdBook == nil {
+		return nil
+	}
+
+	addresses := make([]WithdrawAddress, 0, len(a.wdBook))
+	for _, addr := range a.wdBook {
+		addresses = append(addresses, addr)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].key() < addresses[j].key() })
+	return addresses
+}
+
+// checkWithdraw runs the local guards against the payload. With reserve the
+// quantity is counted in the daily limit, so that concurrent withdrawals
+// cannot exceed it together.
+func (a *Api) checkWithdraw(p models.WalletWithdrawCoinPayload, reserve bool) error {
+	a.wdMu.Lock()
+	defer a.wdMu.Unlock()
+
+	symbol := strings.ToUpper(p.Symbol)
+
+	if a.wdBook != nil {
+		dest := WithdrawAddress{Symbol: symbol, Network: p.Network, Address: p.Address, Tag: p.DestinationTag}
+		if _, ok := a.wdBook[dest.key()]; !ok {
+			return fmt.Errorf("%w: %s address %s (network %q, tag %q) is not in the address book",
+				ErrWithdrawBlocked, symbol, p.Address, p.Network, p.DestinationTag)
+		}
+	}
+
+	if max, ok := a.wdMax[symbol]; ok && p.Quantity.GreaterThan(max) {
+		return fmt.Errorf("%w: %s %s is above the maximum of %s", ErrWithdrawBlocked, p.Quantity, symbol, max)
+	}
+
+	limit, ok := a.wdDaily[symbol]
+	if !ok {
+		return nil
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	sent := decimal.Zero
+	records := a.wdSent[symbol][:0]
+	for _, r := range a.wdSent[symbol] {
+		if r.at.After(since) {
+			records = append(records, r)
+			sent = sent.Add(r.quantity)
+		}
+	}
+	a.wdSent[symbol] = records
+
+	if total := sent.Add(p.Quantity); total.GreaterThan(limit) {
+		return fmt.Errorf("%w: %s %s would take the last 24h to %s, above the limit of %s",
+			ErrWithdrawBlocked, p.Quantity, symbol, total, limit)
+	}
+
+	if reserve {
+		a.wdSent[symbol] = append(a.wdSent[symbol], withdrawRecord{at: time.Now(), quantity: p.Quantity})
+	}
+	return nil
+}
+
+// releaseWithdraw takes back a reservation of checkWithdraw for a withdrawal
+// the exchange refused.
+func (a *Api) releaseWithdraw(p models.WalletWithdrawCoinPayload) {
+	a.wdMu.Lock()
+	defer a.wdMu.Unlock()
+
+	symbol := strings.ToUpper(p.Symbol)
+	records := a.wdSent[symbol]
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].quantity.Equal(p.Quantity) {
+			a.wdSent[symbol] = append(records[:i], records[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+)
+
+func withdrawBTC(a *Api, quantity string) error {
+	_, err := a.WalletWithdrawCoin(WalletCoinSymbol("BTC"), WalletCoinAddr("bc1qtest"), WalletCoinQty(quantity))
+	return err
+}
+
+func TestWithdrawAddressBook(t *testing.T) {
+	btc := WithdrawAddress{Symbol: "BTC", Network: "bitcoin", Address: "bc1qbook"}
+	xrp := WithdrawAddress{Symbol: "XRP", Address: "rbook", Tag: "42"}
+	a, srv := newMockApi(t, OptWithdrawAddressBook(btc, xrp))
+	for _, symbol := range []string{"BTC", "XRP", "BRL"} {
+		if err := srv.SetBalance(mockserver.DefaultAccountID, symbol, "1000"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		opts    []WalletCoinOptions
+		allowed bool
+	}{
+		{"in the book", []WalletCoinOptions{WalletCoinSymbol("btc"), WalletCoinNetwork("Bitcoin"), WalletCoinAddr("bc1qbook")}, true},
+		{"not in the book", []WalletCoinOptions{WalletCoinSymbol("BTC"), WalletCoinNetwork("bitcoin"), WalletCoinAddr("bc1qother")}, false},
+		{"wrong network", []WalletCoinOptions{WalletCoinSymbol("BTC"), WalletCoinNetwork("lightning"), WalletCoinAddr("bc1qbook")}, false},
+		{"no network", []WalletCoinOptions{WalletCoinSymbol("BTC"), WalletCoinAddr("bc1qbook")}, false},
+		{"other coin", []WalletCoinOptions{WalletCoinSymbol("ETH"), WalletCoinNetwork("bitcoin"), WalletCoinAddr("bc1qbook")}, false},
+		{"tag", []WalletCoinOptions{WalletCoinSymbol("XRP"), WalletCoinAddr("rbook"), WalletCoinTag("42")}, true},
+		{"wrong tag", []WalletCoinOptions{WalletCoinSymbol("XRP"), WalletCoinAddr("rbook"), WalletCoinTag("43")}, false},
+		{"no tag", []WalletCoinOptions{WalletCoinSymbol("XRP"), WalletCoinAddr("rbook")}, false},
+	}
+
+	for _, tt := range tests {
+		opts := append(tt.opts, WalletCoinQty("1"))
+
+		calls := srv.Calls("WALLET_WITHDRAW")
+		_, err := a.WalletWithdrawCoin(opts...)
+		if tt.allowed && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if !tt.allowed {
+			if !errors.Is(err, ErrWithdrawBlocked) {
+				t.Errorf("%s: expected ErrWithdrawBlocked, got %v", tt.name, err)
+			}
+			if srv.Calls("WALLET_WITHDRAW") != calls {
+				t.Errorf("%s: a blocked withdrawal was sent", tt.name)
+			}
+		}
+
+		if _, err := a.WalletWithdrawCoinDryRun(opts...); (err == nil) != tt.allowed {
+			t.Errorf("%s dry run: allowed is %v, want %v (%v)", tt.name, err == nil, tt.allowed, err)
+		}
+	}
+
+	// BRL withdrawals are checked by their account_ref.
+	ref := srv.AddBankAccount(mockserver.DefaultAccountID)
+	if _, err := a.WalletWithdrawFiat(WalletFiatAccRef(ref), WalletFiatQty("10")); !errors.Is(err, ErrWithdrawBlocked) {
+		t.Errorf("expected ErrWithdrawBlocked for BRL, got %v", err)
+	}
+	brl := WithdrawAddress{Symbol: "brl", Address: strconv.Itoa(ref)}
+	a.AddWithdrawAddress(brl)
+	if _, err := a.WalletWithdrawFiat(WalletFiatAccRef(ref), WalletFiatQty("10")); err != nil {
+		t.Errorf("unexpected error for a BRL account in the book: %v", err)
+	}
+
+	if got := a.WithdrawAddresses(); len(got) != 3 || got[0].Symbol != "BRL" {
+		t.Errorf("address book %+v, want 3 entries starting with BRL", got)
+	}
+
+	// The book stays enforced once emptied.
+	for _, addr := range []WithdrawAddress{btc, xrp, brl} {
+		a.RemoveWithdrawAddress(addr)
+	}
+	if got := a.WithdrawAddresses(); got == nil || len(got) != 0 {
+		t.Errorf("address book %+v, want empty", got)
+	}
+	if _, err := a.WalletWithdrawCoin(append(tests[0].opts, WalletCoinQty("1"))...); !errors.Is(err, ErrWithdrawBlocked) {
+		t.Errorf("expected ErrWithdrawBlocked with an empty book, got %v", err)
+	}
+
+	// Without a book any address goes.
+	a, srv = newMockApi(t)
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BTC", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if a.WithdrawAddresses() != nil {
+		t.Error("address book enforced without OptWithdrawAddressBook")
+	}
+	if err := withdrawBTC(a, "0.1"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithdrawMax(t *testing.T) {
+	a, srv := newMockApi(t, OptWithdrawMax("btc", "0.5"))
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BTC", "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := withdrawBTC(a, "0.50000001"); !errors.Is(err, ErrWithdrawBlocked) {
+		t.Errorf("expected ErrWithdrawBlocked above the maximum, got %v", err)
+	}
+	if err := withdrawBTC(a, "0.5"); err != nil {
+		t.Errorf("unexpected error at the maximum: %v", err)
+	}
+	// The maximum is per withdrawal.
+	if err := withdrawBTC(a, "0.5"); err != nil {
+		t.Errorf("unexpected error for a second withdrawal: %v", err)
+	}
+	if calls := srv.Calls("WALLET_WITHDRAW"); calls != 2 {
+		t.Errorf("%d withdrawals sent, want 2", calls)
+	}
+
+	if _, err := New(OptWithdrawMax("BTC", "-1")); err == nil {
+		t.Error("expected an error for a negative maximum")
+	}
+}
+
+func TestWithdrawDailyLimit(t *testing.T) {
+	a, srv := newMockApi(t, OptWithdrawDailyLimit("BTC", "1"))
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BTC", "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := withdrawBTC(a, "0.6"); err != nil {
+		t.Fatal(err)
+	}
+	if err := withdrawBTC(a, "0.5"); !errors.Is(err, ErrWithdrawBlocked) {
+		t.Errorf("expected ErrWithdrawBlocked above the daily limit, got %v", err)
+	}
+	if err := withdrawBTC(a, "0.4"); err != nil {
+		t.Errorf("unexpected error up to the daily limit: %v", err)
+	}
+	if err := withdrawBTC(a, "0.00000001"); !errors.Is(err, ErrWithdrawBlocked) {
+		t.Errorf("expected ErrWithdrawBlocked once the limit is reached, got %v", err)
+	}
+	if calls := srv.Calls("WALLET_WITHDRAW"); calls != 2 {
+		t.Errorf("%d withdrawals sent, want 2", calls)
+	}
+}
+
+func TestWithdrawRelease(t *testing.T) {
+	a, srv := newMockApi(t, OptWithdrawDailyLimit("BTC", "1"))
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BTC", "0.5"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A withdrawal refused with a 4xx gives its reservation back.
+	if err := withdrawBTC(a, "0.8"); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+	}
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BTC", "10"); err != nil {
+		t.Fatal(err)
+	}
+	if err := withdrawBTC(a, "1"); err != nil {
+		t.Errorf("the refused withdrawal still counts: %v", err)
+	}
+
+	// One failing with a 5xx may have gone through, so it keeps counting.
+	a, srv = newMockApi(t, OptWithdrawDailyLimit("BTC", "1"))
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BTC", "10"); err != nil {
+		t.Fatal(err)
+	}
+	srv.Fail("WALLET_WITHDRAW", mockserver.Failure{Status: http.StatusInternalServerError, Times: 3})
+	apiErr := &APIError{}
+	if err := withdrawBTC(a, "0.8"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected a 500, got %v", err)
+	}
+	if err := withdrawBTC(a, "0.5"); !errors.Is(err, ErrWithdrawBlocked) {
+		t.Errorf("expected ErrWithdrawBlocked after a 5xx, got %v", err)
+	}
+}
+
+func TestWithdrawDryRunLimit(t *testing.T) {
+	a, srv := newMockApi(t, OptWithdrawDailyLimit("BTC", "1"))
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BTC", "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	dryRun := func(quantity string) error {
+		_, err := a.WalletWithdrawCoinDryRun(WalletCoinSymbol("BTC"), WalletCoinAddr("bc1qtest"), WalletCoinQty(quantity))
+		return err
+	}
+
+	// Dry runs are checked against the limit without counting in it.
+	for i := 0; i < 3; i++ {
+		if err := dryRun("0.8"); err != nil {
+			t.Fatalf("dry run %d: %v", i, err)
+		}
+	}
+	if err := dryRun("1.2"); !errors.Is(err, ErrWithdrawBlocked) {
+		t.Errorf("expected ErrWithdrawBlocked for a dry run above the limit, got %v", err)
+	}
+	if err := withdrawBTC(a, "1"); err != nil {
+		t.Errorf("dry runs counted in the limit: %v", err)
+	}
+	if err := dryRun("0.1"); !errors.Is(err, ErrWithdrawBlocked) {
+		t.Errorf("expected ErrWithdrawBlocked for a dry run once the limit is reached, got %v", err)
+	}
+	if calls := srv.Calls("WALLET_WITHDRAW"); calls != 1 {
+		t.Errorf("%d withdrawals sent, want 1", calls)
+	}
+}
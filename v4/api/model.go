@@ -1,8 +1,21 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/config"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/paper"
 	"github.com/thiagozs/go-mbsdk/v4/pkg/cache"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/caller"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/client"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/ratelimit"
 )
 
 type Kind int
@@ -19,8 +32,38 @@ func (k Kind) String() string {
 }
 
 type Api struct {
-	cache *cache.Cache
-	log   zerolog.Logger
+	cfg    *config.Configure
+	cache  *cache.Cache
+	log    zerolog.Logger
+	authMu sync.Mutex
+
+	accMu   sync.RWMutex
+	account AccountSelector
+
+	validateOrders bool
+	autoRound      bool
+	rulesMu        sync.RWMutex
+	ruleOverrides  map[string]OrderRules
+
+	symMu        sync.RWMutex
+	symRefreshMu sync.Mutex
+	symbols      map[string]models.SymbolInfo
+	symbolsAt    time.Time
+	symbolsTTL   time.Duration
+
+	limiter *ratelimit.Limiter
+	rest    client.HttpClientPort
+
+	placeRetries   int
+	placeRetryWait time.Duration
+
+	paper *paper.Account
+
+	wdMu    sync.Mutex
+	wdBook  map[string]WithdrawAddress
+	wdMax   map[string]decimal.Decimal
+	wdDaily map[string]decimal.Decimal
+	wdSent  map[string][]withdrawRecord
 }
 
 type Options func(o *ApiCfg) error
@@ -31,6 +74,30 @@ type ApiCfg struct {
 	secret   string
 	debug    bool
 	endpoint string
+	account  AccountSelector
+
+	validateOrders bool
+	autoRound      bool
+	orderRules     []OrderRules
+	symbolsTTL     time.Duration
+
+	limiter        *ratelimit.Limiter
+	disableLimiter bool
+
+	httpClient  *http.Client
+	transport   http.RoundTripper
+	retryPolicy *client.RetryPolicy
+
+	placeRetries   int
+	placeRetryWait time.Duration
+
+	paperTrading bool
+	paperOpts    []paper.Options
+
+	withdrawList  bool
+	withdrawBook  []WithdrawAddress
+	withdrawMax   map[string]decimal.Decimal
+	withdrawDaily map[string]decimal.Decimal
 }
 
 func OptCache(cache *cache.Cache) Options {
@@ -67,3 +134,218 @@ func OptEndpoint(endpoint string) Options {
 		return nil
 	}
 }
+
+func OptAccountID(id string) Options {
+	return func(a *ApiCfg) error {
+		a.account.ID = id
+		return nil
+	}
+}
+
+func OptAccountName(name string) Options {
+	return func(a *ApiCfg) error {
+		a.account.Name = name
+		return nil
+	}
+}
+
+func OptAccountCurrency(currency string) Options {
+	return func(a *ApiCfg) error {
+		a.account.Currency = currency
+		return nil
+	}
+}
+
+// OptValidateOrders checks every order against the symbol trading rules before
+// PlaceOrder sends it.
+func OptValidateOrders(on bool) Options {
+	return func(a *ApiCfg) error {
+		a.validateOrders = on
+		return nil
+	}
+}
+
+// OptAutoRound makes the order validation round price and quantity to the
+// symbol tick size and quantity step instead of failing.
+func OptAutoRound(on bool) Options {
+	return func(a *ApiCfg) error {
+		a.autoRound = on
+		return nil
+	}
+}
+
+func OptOrderRules(rules ...OrderRules) Options {
+	return func(a *ApiCfg) error {
+		a.orderRules = append(a.orderRules, rules...)
+		return nil
+	}
+}
+
+// OptSymbolsTTL sets how long the symbol registry is used before it is
+// loaded again from the Symbols endpoint, one hour by default.
+func OptSymbolsTTL(ttl time.Duration) Options {
+	return func(a *ApiCfg) error {
+		if ttl <= 0 {
+			return fmt.Errorf("invalid symbols ttl %s", ttl)
+		}
+		a.symbolsTTL = ttl
+		return nil
+	}
+}
+
+// OptRateLimits replaces the default request budget of each endpoint group.
+// Groups left out are not limited.
+func OptRateLimits(limits map[ratelimit.Group]ratelimit.Limit) Options {
+	return func(a *ApiCfg) error {
+		for g, l := range limits {
+			if l.Rate < 0 || l.Burst < 0 {
+				return fmt.Errorf("invalid rate limit for %s", g)
+			}
+		}
+		a.limiter = ratelimit.New(limits)
+		return nil
+	}
+}
+
+// OptRateLimiter shares one limiter between several Api instances using the
+// same credentials. A nil limiter disables client-side limiting.
+func OptRateLimiter(l *ratelimit.Limiter) Options {
+	return func(a *ApiCfg) error {
+		a.limiter = l
+		a.disableLimiter = l == nil
+		return nil
+	}
+}
+
+// OptHTTPClient sends every REST request through hc, for proxies, custom TLS
+// roots or connection pool settings. The client is copied, never modified.
+func OptHTTPClient(hc *http.Client) Options {
+	return func(a *ApiCfg) error {
+		a.httpClient = hc
+		return nil
+	}
+}
+
+// OptTransport replaces the transport of the REST client, e.g. with the one
+// of an httptest server.
+func OptTransport(rt http.RoundTripper) Options {
+	return func(a *ApiCfg) error {
+		a.transport = rt
+		return nil
+	}
+}
+
+func OptRetryPolicy(policy client.RetryPolicy) Options {
+	return func(a *ApiCfg) error {
+		if err := caller.OptRetryPolicy(policy)(&caller.CallerCfg{}); err != nil {
+			return err
+		}
+		a.retryPolicy = &policy
+		return nil
+	}
+}
+
+// OptPlaceOrderRetry sets how many times an order is sent again after a
+// failure without answer, and the wait before each ListOrders search for it.
+// Each retry happens only after two searches, the last one right before the
+// resend, show the order was not created. The wait should cover how long the
+// exchange takes to list a new order.
+func OptPlaceOrderRetry(retries int, wait time.Duration) Options {
+	return func(a *ApiCfg) error {
+		if retries < 0 || wait < 0 {
+			return fmt.Errorf("invalid place order retry %d - %s", retries, wait)
+		}
+		a.placeRetries = retries
+		a.placeRetryWait = wait
+		return nil
+	}
+}
+
+// OptPaperTrading sends PlaceOrder, CancelOrder, GetOrder, ListOrders and
+// GetBalances to a simulated account instead of the exchange. By default the
+// orders fill against the live order book; use paper.OptMarketData to replay
+// recorded books.
+func OptPaperTrading(opts ...paper.Options) Options {
+	return func(a *ApiCfg) error {
+		a.paperTrading = true
+		a.paperOpts = append(a.paperOpts, opts...)
+		return nil
+	}
+}
+
+// OptWithdrawAddressBook only allows withdrawals to the given addresses. See
+// AddWithdrawAddress.
+func OptWithdrawAddressBook(addresses ...WithdrawAddress) Options {
+	return func(a *ApiCfg) error {
+		for _, addr := range addresses {
+			if len(addr.Symbol) == 0 || len(addr.Address) == 0 {
+				return fmt.Errorf("invalid withdraw address %+v", addr)
+			}
+		}
+		a.withdrawList = true
+		a.withdrawBook = append(a.withdrawBook, addresses...)
+		return nil
+	}
+}
+
+// OptWithdrawMax rejects single withdrawals of symbol above amount.
+func OptWithdrawMax(symbol, amount string) Options {
+	return func(a *ApiCfg) error {
+		value, err := decimal.NewFromString(amount)
+		if err != nil || !value.IsPositive() {
+			return fmt.Errorf("invalid withdraw max %q for %s", amount, symbol)
+		}
+		if a.withdrawMax == nil {
+			a.withdrawMax = make(map[string]decimal.Decimal)
+		}
+		a.withdrawMax[strings.ToUpper(symbol)] = value
+		return nil
+	}
+}
+
+// OptWithdrawDailyLimit rejects withdrawals of symbol that would take the
+// amount sent by this Api in the last 24 hours above amount.
+func OptWithdrawDailyLimit(symbol, amount string) Options {
+	return func(a *ApiCfg) error {
+		value, err := decimal.NewFromString(amount)
+		if err != nil || !value.IsPositive() {
+			return fmt.Errorf("invalid withdraw daily limit %q for %s", amount, symbol)
+		}
+		if a.withdrawDaily == nil {
+			a.withdrawDaily = make(map[string]decimal.Decimal)
+		}
+		a.withdrawDaily[strings.ToUpper(symbol)] = value
+		return nil
+	}
+}
+
+type AccountOptions func(s *AccountSelector) error
+
+// AccountSelector picks one of the accounts of the logged user. Every
+// non-empty field must match; an empty selector means the first account.
+type AccountSelector struct {
+	ID       string
+	Name     string
+	Currency string
+}
+
+func AccID(id string) AccountOptions {
+	return func(s *AccountSelector) error {
+		s.ID = id
+		return nil
+	}
+}
+
+func AccName(name string) AccountOptions {
+	return func(s *AccountSelector) error {
+		s.Name = name
+		return nil
+	}
+}
+
+func AccCurrency(currency string) AccountOptions {
+	return func(s *AccountSelector) error {
+		s.Currency = currency
+		return nil
+	}
+}
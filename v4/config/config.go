@@ -9,10 +9,11 @@ const (
 	AUTHORIZE
 	BALANCE
 	ORDERS_INDEX
+	POSITIONS
 )
 
 func (c CacheT) String() string {
-	return [...]string{"ACCOUNTS", "AUTHORIZE", "BALANCE", "ORDERS_INDEX"}[c]
+	return [...]string{"ACCOUNTS", "AUTHORIZE", "BALANCE", "ORDERS_INDEX", "POSITIONS"}[c]
 }
 
 var EndPoints = map[string]string{
@@ -22,7 +23,7 @@ var EndPoints = map[string]string{
 	// ACCOUNT
 	"ACCOUNTS":      "https://api.mercadobitcoin.net/api/v4/accounts",
 	"BALANCE_LIST":  "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/balances",
-	"POSITION_LIST": "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/positions", // TODO:
+	"POSITION_LIST": "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/positions",
 
 	// TRADING
 	"ORDER_GET":        "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/{symbol}/orders/{orderId}",
@@ -32,9 +33,10 @@ var EndPoints = map[string]string{
 	"ORDER_CANCEL_ALL": "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/cancel_all_open_orders",
 
 	// WALLET
-	"WALLET_DEPOSIT":     "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/wallet/{symbol}/deposits",
-	"WALLET_WITHDRAW":    "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/wallet/{symbol}/withdraw", // TODO:
-	"WALLET_GETWITHDRAW": "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/wallet/{symbol}/withdraw/{withdrawId}",
+	"WALLET_DEPOSIT":      "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/wallet/{symbol}/deposits",
+	"WALLET_WITHDRAW":     "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/wallet/{symbol}/withdraw",
+	"WALLET_GETWITHDRAW":  "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/wallet/{symbol}/withdraw/{withdrawId}",
+	"WALLET_LISTWITHDRAW": "https://api.mercadobitcoin.net/api/v4/accounts/{accountId}/wallet/{symbol}/withdraw",
 
 	// PUBLIC DATA
 	"ORDERBOOK": "https://api.mercadobitcoin.net/api/v4/{symbol}/orderbook",
@@ -44,8 +46,8 @@ var EndPoints = map[string]string{
 	"TICKERS":   "https://api.mercadobitcoin.net/api/v4/tickers",
 }
 
-var Config Configure
-
+// Configure holds the settings of a single Api instance. Each instance owns its
+// copy, so clients with different credentials can live in the same process.
 type Configure struct {
 	Debug    bool         `json:"debug"`
 	Login    string       `json:"login"`
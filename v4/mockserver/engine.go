@@ -0,0 +1,337 @@
+package mockserver
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/utils"
+)
+
+// qtyPlaces is the precision used when a market buy by cost is converted to
+// quantity.
+const qtyPlaces = 8
+
+type rejection struct {
+	status  int
+	code    string
+	message string
+}
+
+func reject(code, format string, args ...interface{}) *rejection {
+	return &rejection{status: http.StatusBadRequest, code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// AddLiquidity rests an order owned by nobody on the book of symbol, for the
+// orders of the accounts to trade against. side is "buy" or "sell".
+func (s *Server) AddLiquidity(symbol, side, price, qty string) error {
+	p, err := decimal.NewFromString(price)
+	if err != nil {
+		return err
+	}
+	q, err := decimal.NewFromString(qty)
+	if err != nil {
+		return err
+	}
+	if side != "buy" && side != "sell" {
+		return fmt.Errorf("invalid side %q", side)
+	}
+	if !p.IsPositive() || !q.IsPositive() {
+		return fmt.Errorf("price and quantity must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Unix()
+	id := s.nextID()
+	o := &order{
+		ID:         fmt.Sprint(id),
+		Instrument: strings.ToUpper(symbol),
+		Side:       side,
+		Type:       "limit",
+		LimitPrice: p,
+		Qty:        q,
+		Status:     "working",
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		seq:        id,
+	}
+	s.orders[o.ID] = o
+	s.match(o)
+	s.rest(o)
+	s.triggerStops(o.Instrument)
+	return nil
+}
+
+// submit reserves the funds of o and matches it. Callers hold s.mu.
+func (s *Server) submit(o *order) *rejection {
+	base, quote := utils.PairQuote(o.Instrument)
+
+	switch {
+	case o.Side == "sell":
+		if r := s.reserve(o, base, o.Qty); r != nil {
+			return r
+		}
+	case o.Type == "market" && o.Qty.IsZero():
+		if r := s.reserve(o, quote, o.cost); r != nil {
+			return r
+		}
+	case o.Type == "market":
+		if r := s.reserve(o, quote, s.estimate(o)); r != nil {
+			return r
+		}
+	default:
+		if r := s.reserve(o, quote, o.Qty.Mul(o.LimitPrice)); r != nil {
+			return r
+		}
+	}
+
+	s.orders[o.ID] = o
+	o.accepted = time.Now()
+
+	if o.Type == "stoplimit" {
+		o.Status = "created"
+		s.stops[o.Instrument] = append(s.stops[o.Instrument], o)
+		return nil
+	}
+
+	o.Status = "working"
+	s.match(o)
+	s.rest(o)
+	s.triggerStops(o.Instrument)
+	return nil
+}
+
+func (s *Server) reserve(o *order, asset string, amount decimal.Decimal) *rejection {
+	b := s.wallet(o.account, asset)
+	if b.available.LessThan(amount) {
+		return reject("TRADING|INSUFFICIENT_BALANCE", "insufficient %s balance: %s available, %s required",
+			asset, b.available, amount)
+	}
+	b.available = b.available.Sub(amount)
+	b.onHold = b.onHold.Add(amount)
+	o.held = amount
+	return nil
+}
+
+// estimate is the quote needed to fill a market buy by quantity against the
+// asks currently on the book.
+func (s *Server) estimate(o *order) decimal.Decimal {
+	remaining := o.Qty
+	total := decimal.Zero
+	for _, ask := range s.asks[o.Instrument] {
+		if !remaining.IsPositive() {
+			break
+		}
+		qty := decimal.Min(remaining, ask.remaining())
+		total = total.Add(qty.Mul(ask.LimitPrice))
+		remaining = remaining.Sub(qty)
+	}
+	return total
+}
+
+func (o *order) remaining() decimal.Decimal {
+	return o.Qty.Sub(o.FilledQty)
+}
+
+// open reports whether o can still be filled. A market buy by cost has no
+// quantity and stays open while there is quote reserved.
+func (o *order) open() bool {
+	if o.Type == "market" && o.Qty.IsZero() {
+		return o.held.IsPositive()
+	}
+	return o.remaining().IsPositive()
+}
+
+// match fills o against the opposite side of the book, best price first and
+// oldest order first within a price.
+func (s *Server) match(o *order) {
+	for o.open() {
+		book := s.asks[o.Instrument]
+		if o.Side == "sell" {
+			book = s.bids[o.Instrument]
+		}
+		if len(book) == 0 {
+			return
+		}
+
+		maker := book[0]
+		price := maker.LimitPrice
+
+		if o.Type != "market" {
+			if o.Side == "buy" && price.GreaterThan(o.LimitPrice) {
+				return
+			}
+			if o.Side == "sell" && price.LessThan(o.LimitPrice) {
+				return
+			}
+		}
+
+		qty := maker.remaining()
+		if o.Type == "market" && o.Qty.IsZero() {
+			qty = decimal.Min(qty, o.held.Div(price).RoundDown(qtyPlaces))
+		} else {
+			qty = decimal.Min(qty, o.remaining())
+		}
+		if !qty.IsPositive() {
+			return
+		}
+
+		s.fill(o, price, qty, s.cfg.takerFee)
+		s.fill(maker, price, qty, s.cfg.makerFee)
+
+		s.trades[o.Instrument] = append(s.trades[o.Instrument], trade{
+			Amount: qty,
+			Date:   time.Now().Unix(),
+			Price:  price,
+			Tid:    s.nextID(),
+			Type:   o.Side,
+		})
+
+		if !maker.open() {
+			maker.Status = "filled"
+			s.release(maker)
+			s.removeFromBook(maker)
+		}
+	}
+}
+
+func (s *Server) fill(o *order, price, qty, feeRate decimal.Decimal) {
+	base, quote := utils.PairQuote(o.Instrument)
+	notional := price.Mul(qty)
+
+	o.AvgPrice = o.AvgPrice.Mul(o.FilledQty).Add(notional).Div(o.FilledQty.Add(qty))
+	o.FilledQty = o.FilledQty.Add(qty)
+	o.UpdatedAt = time.Now().Unix()
+	o.Executions = append(o.Executions, execution{
+		ExecutedAt: o.UpdatedAt,
+		FeeRate:    feeRate,
+		ID:         fmt.Sprint(s.nextID()),
+		Instrument: o.Instrument,
+		Price:      price,
+		Qty:        qty,
+		Side:       o.Side,
+	})
+
+	if len(o.account) == 0 {
+		return
+	}
+
+	if o.Side == "buy" {
+		fee := qty.Mul(feeRate)
+		o.held = o.held.Sub(notional)
+		s.wallet(o.account, quote).onHold = s.wallet(o.account, quote).onHold.Sub(notional)
+		s.wallet(o.account, base).available = s.wallet(o.account, base).available.Add(qty.Sub(fee))
+		o.Fee = o.Fee.Add(fee)
+		return
+	}
+
+	fee := notional.Mul(feeRate)
+	o.held = o.held.Sub(qty)
+	s.wallet(o.account, base).onHold = s.wallet(o.account, base).onHold.Sub(qty)
+	s.wallet(o.account, quote).available = s.wallet(o.account, quote).available.Add(notional.Sub(fee))
+	o.Fee = o.Fee.Add(fee)
+}
+
+// rest puts what is left of a limit order on the book and closes any other
+// order, releasing the funds it no longer needs.
+func (s *Server) rest(o *order) {
+	if o.Type != "market" && o.open() {
+		book := s.bids
+		better := func(a, b *order) bool { return a.LimitPrice.GreaterThan(b.LimitPrice) }
+		if o.Side == "sell" {
+			book = s.asks
+			better = func(a, b *order) bool { return a.LimitPrice.LessThan(b.LimitPrice) }
+		}
+
+		levels := book[o.Instrument]
+		i := sort.Search(len(levels), func(i int) bool { return better(o, levels[i]) })
+		levels = append(levels, nil)
+		copy(levels[i+1:], levels[i:])
+		levels[i] = o
+		book[o.Instrument] = levels
+		return
+	}
+
+	if o.FilledQty.IsPositive() {
+		o.Status = "filled"
+	} else {
+		o.Status = "cancelled"
+	}
+	s.release(o)
+}
+
+func (s *Server) release(o *order) {
+	if len(o.account) == 0 || !o.held.IsPositive() {
+		o.held = decimal.Zero
+		return
+	}
+
+	base, quote := utils.PairQuote(o.Instrument)
+	asset := quote
+	if o.Side == "sell" {
+		asset = base
+	}
+
+	b := s.wallet(o.account, asset)
+	b.onHold = b.onHold.Sub(o.held)
+	b.available = b.available.Add(o.held)
+	o.held = decimal.Zero
+}
+
+func (s *Server) removeFromBook(o *order) {
+	for _, book := range []map[string][]*order{s.bids, s.asks, s.stops} {
+		levels := book[o.Instrument]
+		for i, l := range levels {
+			if l == o {
+				book[o.Instrument] = append(levels[:i], levels[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// cancel closes an open order and returns its reserved funds.
+func (s *Server) cancel(o *order) bool {
+	if o.Status != "working" && o.Status != "created" {
+		return false
+	}
+	s.removeFromBook(o)
+	s.release(o)
+	o.Status = "cancelled"
+	o.UpdatedAt = time.Now().Unix()
+	return true
+}
+
+// triggerStops activates the stop orders reached by the last trade price.
+func (s *Server) triggerStops(symbol string) {
+	for {
+		trades := s.trades[symbol]
+		if len(trades) == 0 {
+			return
+		}
+		last := trades[len(trades)-1].Price
+
+		var triggered *order
+		for _, o := range s.stops[symbol] {
+			if (o.Side == "buy" && last.GreaterThanOrEqual(o.StopPrice)) ||
+				(o.Side == "sell" && last.LessThanOrEqual(o.StopPrice)) {
+				triggered = o
+				break
+			}
+		}
+		if triggered == nil {
+			return
+		}
+
+		s.removeFromBook(triggered)
+		triggered.Status = "working"
+		triggered.UpdatedAt = time.Now().Unix()
+		s.match(triggered)
+		s.rest(triggered)
+	}
+}
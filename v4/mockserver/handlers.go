@@ -0,0 +1,699 @@
+package mockserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/utils"
+)
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "API|INVALID_FORM", err.Error())
+		return
+	}
+
+	login, password := r.PostForm.Get("login"), r.PostForm.Get("password")
+	if len(s.cfg.login) > 0 && (login != s.cfg.login || password != s.cfg.password) {
+		writeError(w, http.StatusUnauthorized, "API|UNAUTHORIZED", "invalid credentials")
+		return
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		writeError(w, http.StatusInternalServerError, "API|INTERNAL_ERROR", err.Error())
+		return
+	}
+	token := hex.EncodeToString(b)
+	expiration := time.Now().Add(s.cfg.tokenTTL)
+
+	s.mu.Lock()
+	s.tokens[token] = expiration
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, models.AuthoritionToken{
+		AccessToken: token,
+		Expiration:  int(expiration.Unix()),
+	})
+}
+
+func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	writeJSON(w, http.StatusOK, s.cfg.accounts)
+}
+
+func (s *Server) listBalances(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	type item struct {
+		Available decimal.Decimal `json:"available"`
+		OnHold    decimal.Decimal `json:"on_hold"`
+		Symbol    string          `json:"symbol"`
+		Total     decimal.Decimal `json:"total"`
+	}
+
+	s.mu.Lock()
+	items := []item{}
+	for asset, b := range s.balances[vars["accountId"]] {
+		items = append(items, item{
+			Available: b.available,
+			OnHold:    b.onHold,
+			Symbol:    asset,
+			Total:     b.available.Add(b.onHold),
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Symbol < items[j].Symbol })
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) listPositions(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	type item struct {
+		AvgPrice   decimal.Decimal `json:"avgPrice"`
+		Category   string          `json:"category"`
+		ID         string          `json:"id"`
+		Instrument string          `json:"instrument"`
+		Qty        decimal.Decimal `json:"qty"`
+		Side       string          `json:"side"`
+	}
+
+	symbols := splitList(r.URL.Query().Get("symbols"))
+
+	s.mu.Lock()
+	items := []item{}
+	for _, o := range s.accountOrders(vars["accountId"], "") {
+		if o.Status != "working" {
+			continue
+		}
+		if len(symbols) > 0 && !symbols[o.Instrument] {
+			continue
+		}
+		items = append(items, item{
+			AvgPrice:   o.LimitPrice,
+			Category:   o.Type,
+			ID:         o.ID,
+			Instrument: o.Instrument,
+			Qty:        o.remaining(),
+			Side:       o.Side,
+		})
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) getOrder(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[vars["orderId"]]
+	if !ok || o.account != vars["accountId"] || o.Instrument != strings.ToUpper(vars["symbol"]) {
+		writeError(w, http.StatusNotFound, "TRADING|ORDER_NOT_FOUND", fmt.Sprintf("order %s not found", vars["orderId"]))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (s *Server) placeOrder(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	payload := struct {
+		Async      bool            `json:"async"`
+		Cost       decimal.Decimal `json:"cost"`
+		LimitPrice decimal.Decimal `json:"limitPrice"`
+		Qty        decimal.Decimal `json:"qty"`
+		Side       string          `json:"side"`
+		StopPrice  decimal.Decimal `json:"stopPrice"`
+		Type       string          `json:"type"`
+		ExternalID string          `json:"externalId"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "TRADING|INVALID_PAYLOAD", err.Error())
+		return
+	}
+
+	symbol := strings.ToUpper(vars["symbol"])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rej := s.checkSymbol(symbol); rej != nil {
+		writeError(w, rej.status, rej.code, rej.message)
+		return
+	}
+
+	switch {
+	case payload.Side != "buy" && payload.Side != "sell":
+		writeError(w, http.StatusBadRequest, "TRADING|INVALID_SIDE", fmt.Sprintf("invalid side %q", payload.Side))
+		return
+	case payload.Type != "limit" && payload.Type != "market" && payload.Type != "stoplimit":
+		writeError(w, http.StatusBadRequest, "TRADING|INVALID_TYPE", fmt.Sprintf("invalid type %q", payload.Type))
+		return
+	case payload.Type != "market" && !payload.LimitPrice.IsPositive():
+		writeError(w, http.StatusBadRequest, "TRADING|INVALID_PRICE", "limitPrice is required")
+		return
+	case payload.Type == "stoplimit" && !payload.StopPrice.IsPositive():
+		writeError(w, http.StatusBadRequest, "TRADING|INVALID_PRICE", "stopPrice is required")
+		return
+	case payload.Qty.IsNegative() || payload.Cost.IsNegative():
+		writeError(w, http.StatusBadRequest, "TRADING|INVALID_QUANTITY", "qty and cost must be positive")
+		return
+	case payload.Qty.IsZero() && !(payload.Type == "market" && payload.Side == "buy" && payload.Cost.IsPositive()):
+		writeError(w, http.StatusBadRequest, "TRADING|INVALID_QUANTITY", "qty is required")
+		return
+	}
+
+	now := time.Now().Unix()
+	id := s.nextID()
+	o := &order{
+		CreatedAt:  now,
+		ExternalID: payload.ExternalID,
+		ID:         fmt.Sprint(id),
+		Instrument: symbol,
+		LimitPrice: payload.LimitPrice,
+		Qty:        payload.Qty,
+		Side:       payload.Side,
+		StopPrice:  payload.StopPrice,
+		Type:       payload.Type,
+		UpdatedAt:  now,
+		account:    vars["accountId"],
+		seq:        id,
+		cost:       payload.Cost,
+	}
+	if o.Type == "market" {
+		o.LimitPrice = decimal.Zero
+	}
+
+	if rej := s.submit(o); rej != nil {
+		writeError(w, rej.status, rej.code, rej.message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.PlaceOrderResponse{OrderID: o.ID})
+}
+
+func (s *Server) checkSymbol(symbol string) *rejection {
+	if !strings.Contains(symbol, "-") {
+		return reject("TRADING|INVALID_SYMBOL", "invalid symbol %s", symbol)
+	}
+	if len(s.symbols.Symbol) == 0 {
+		return nil
+	}
+	for _, listed := range s.symbols.Symbol {
+		if strings.EqualFold(listed, symbol) {
+			return nil
+		}
+	}
+	return reject("TRADING|INVALID_SYMBOL", "symbol %s not found", symbol)
+}
+
+func (s *Server) cancelOrder(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[vars["orderId"]]
+	if !ok || o.account != vars["accountId"] || o.Instrument != strings.ToUpper(vars["symbol"]) {
+		writeError(w, http.StatusNotFound, "TRADING|ORDER_NOT_FOUND", fmt.Sprintf("order %s not found", vars["orderId"]))
+		return
+	}
+
+	if !s.cancel(o) {
+		writeError(w, http.StatusBadRequest, "TRADING|ORDER_ALREADY_CLOSED", fmt.Sprintf("order %s is %s", o.ID, o.Status))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": o.Status})
+}
+
+func (s *Server) listOrders(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []*order{}
+	for _, o := range s.accountOrders(vars["accountId"], strings.ToUpper(vars["symbol"])) {
+		if v := q.Get("side"); len(v) > 0 && o.Side != v {
+			continue
+		}
+		if v := q.Get("status"); len(v) > 0 && o.Status != v {
+			continue
+		}
+		if v := q.Get("has_executions"); len(v) > 0 && (v == "true") != (len(o.Executions) > 0) {
+			continue
+		}
+		if !inRange(o.seq, q.Get("id_from"), q.Get("id_to")) {
+			continue
+		}
+		if !inRange(o.CreatedAt, q.Get("created_at_from"), q.Get("created_at_to")) {
+			continue
+		}
+		if time.Since(o.accepted) < s.cfg.listLag {
+			continue
+		}
+		items = append(items, o)
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) cancelAllOrders(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	q := r.URL.Query()
+	symbol := strings.ToUpper(q.Get("symbol"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type item struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+
+	items := []item{}
+	for _, o := range s.accountOrders(vars["accountId"], symbol) {
+		if v := q.Get("has_executions"); len(v) > 0 && (v == "true") != (len(o.Executions) > 0) {
+			continue
+		}
+		if s.cancel(o) {
+			items = append(items, item{ID: o.ID, Status: o.Status})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+// accountOrders returns the orders of the account, optionally of one symbol,
+// oldest first. Callers hold s.mu.
+func (s *Server) accountOrders(accountID, symbol string) []*order {
+	orders := []*order{}
+	for _, o := range s.orders {
+		if o.account != accountID || (len(symbol) > 0 && o.Instrument != symbol) {
+			continue
+		}
+		orders = append(orders, o)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].seq < orders[j].seq })
+	return orders
+}
+
+func (s *Server) listDeposits(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	type item struct {
+		Address      string          `json:"address"`
+		AddressTag   string          `json:"addressTag"`
+		Amount       decimal.Decimal `json:"amount"`
+		Coin         string          `json:"coin"`
+		ConfirmTimes string          `json:"confirmTimes"`
+		CreatedAt    int64           `json:"createdAt"`
+		Status       string          `json:"status"`
+		TransferType string          `json:"transferType"`
+	}
+
+	q := r.URL.Query()
+	symbol := strings.ToUpper(vars["symbol"])
+
+	s.mu.Lock()
+	deposits := append([]Deposit{}, s.deposits[vars["accountId"]+"|"+symbol]...)
+	s.mu.Unlock()
+
+	items := []item{}
+	for _, d := range deposits {
+		if !inRange(d.CreatedAt.Unix(), q.Get("from"), q.Get("to")) {
+			continue
+		}
+		amount, _ := decimal.NewFromString(d.Amount)
+		items = append(items, item{
+			Address:      d.Address,
+			AddressTag:   d.AddressTag,
+			Amount:       amount,
+			Coin:         symbol,
+			ConfirmTimes: d.ConfirmTimes,
+			CreatedAt:    d.CreatedAt.Unix(),
+			Status:       d.Status,
+			TransferType: d.TransferType,
+		})
+	}
+
+	start, end := page(len(items), q)
+	writeJSON(w, http.StatusOK, items[start:end])
+}
+
+// page returns the bounds of the page asked by the limit and page query
+// parameters, the whole list without a limit.
+func page(n int, q url.Values) (int, int) {
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		return 0, n
+	}
+
+	p, _ := strconv.Atoi(q.Get("page"))
+	if p < 1 {
+		p = 1
+	}
+	start := (p - 1) * limit
+	if start > n {
+		start = n
+	}
+	end := start + limit
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+func (s *Server) withdraw(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	payload := struct {
+		AccountRef  int    `json:"account_ref"`
+		Address     string `json:"address"`
+		Description string `json:"description"`
+		Quantity    string `json:"quantity"`
+		Symbol      string `json:"symbol"`
+		TxFee       string `json:"tx_fee"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "WALLET|INVALID_PAYLOAD", err.Error())
+		return
+	}
+
+	quantity, err := utils.ParseDecimal(payload.Quantity)
+	if err != nil || !quantity.IsPositive() {
+		writeError(w, http.StatusBadRequest, "WALLET|INVALID_QUANTITY", fmt.Sprintf("invalid quantity %q", payload.Quantity))
+		return
+	}
+
+	fee, err := utils.ParseDecimal(payload.TxFee)
+	if err != nil || fee.IsNegative() {
+		writeError(w, http.StatusBadRequest, "WALLET|INVALID_FEE", fmt.Sprintf("invalid tx_fee %q", payload.TxFee))
+		return
+	}
+
+	symbol := strings.ToUpper(vars["symbol"])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if symbol == "BRL" {
+		if !s.hasBankAccount(vars["accountId"], payload.AccountRef) {
+			writeError(w, http.StatusBadRequest, "WALLET|INVALID_ACCOUNT_REF",
+				fmt.Sprintf("bank account %d is not registered", payload.AccountRef))
+			return
+		}
+	} else if len(payload.Address) == 0 {
+		writeError(w, http.StatusBadRequest, "WALLET|INVALID_ADDRESS", "address is required")
+		return
+	}
+
+	b := s.wallet(vars["accountId"], symbol)
+	if b.available.LessThan(quantity) {
+		writeError(w, http.StatusBadRequest, "WALLET|INSUFFICIENT_BALANCE",
+			fmt.Sprintf("insufficient %s balance: %s available, %s required", symbol, b.available, quantity))
+		return
+	}
+	b.available = b.available.Sub(quantity)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	wd := &withdrawal{
+		Account:     vars["accountId"],
+		AccountRef:  payload.AccountRef,
+		Address:     payload.Address,
+		Coin:        symbol,
+		CreatedAt:   now,
+		Description: payload.Description,
+		Fee:         fee,
+		ID:          int(s.nextID()),
+		NetQuantity: quantity.Sub(fee),
+		Quantity:    quantity,
+		Status:      1,
+		UpdatedAt:   now,
+	}
+	s.withdrawals[wd.ID] = wd
+
+	writeJSON(w, http.StatusOK, wd)
+}
+
+// hasBankAccount reports whether ref is a registered bank account. The caller
+// holds s.mu.
+func (s *Server) hasBankAccount(accountID string, ref int) bool {
+	for _, id := range s.bankAccounts[accountID] {
+		if id == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) listWithdraws(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	q := r.URL.Query()
+	symbol := strings.ToUpper(vars["symbol"])
+
+	s.mu.Lock()
+	items := []withdrawal{}
+	for _, wd := range s.withdrawals {
+		if wd.Account != vars["accountId"] || wd.Coin != symbol {
+			continue
+		}
+		created, _ := time.Parse(time.RFC3339, wd.CreatedAt)
+		if !inRange(created.Unix(), q.Get("from"), q.Get("to")) {
+			continue
+		}
+		items = append(items, *wd)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	start, end := page(len(items), q)
+	writeJSON(w, http.StatusOK, items[start:end])
+}
+
+func (s *Server) getWithdraw(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	id, _ := strconv.Atoi(vars["withdrawId"])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wd, ok := s.withdrawals[id]
+	if !ok || wd.Account != vars["accountId"] || wd.Coin != strings.ToUpper(vars["symbol"]) {
+		writeError(w, http.StatusNotFound, "WALLET|WITHDRAW_NOT_FOUND", fmt.Sprintf("withdraw %s not found", vars["withdrawId"]))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, wd)
+}
+
+func (s *Server) orderBook(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	symbol := strings.ToUpper(vars["symbol"])
+
+	s.mu.Lock()
+	book := models.OrderBookResponse{
+		Asks:      aggregate(s.asks[symbol], limit),
+		Bids:      aggregate(s.bids[symbol], limit),
+		Timestamp: int(time.Now().UnixNano() / int64(time.Millisecond)),
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, book)
+}
+
+// aggregate sums the resting orders of one side by price, keeping at most
+// limit levels when limit is positive.
+func aggregate(orders []*order, limit int) []models.OrderBookLevel {
+	levels := []models.OrderBookLevel{}
+	for _, o := range orders {
+		n := len(levels)
+		if n > 0 && levels[n-1].Price.Equal(o.LimitPrice) {
+			levels[n-1].Quantity = levels[n-1].Quantity.Add(o.remaining())
+			continue
+		}
+		if limit > 0 && n == limit {
+			break
+		}
+		levels = append(levels, models.OrderBookLevel{Price: o.LimitPrice, Quantity: o.remaining()})
+	}
+	return levels
+}
+
+func (s *Server) listTrades(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	q := r.URL.Query()
+	symbol := strings.ToUpper(vars["symbol"])
+
+	s.mu.Lock()
+	trades := append([]trade{}, s.trades[symbol]...)
+	s.mu.Unlock()
+
+	items := []trade{}
+	for _, t := range trades {
+		if !inRange(t.Tid, q.Get("since"), "") || !inRange(t.Date, q.Get("from"), q.Get("to")) {
+			continue
+		}
+		items = append(items, t)
+	}
+
+	if limit, _ := strconv.Atoi(q.Get("limit")); limit > 0 && len(items) > limit {
+		items = items[len(items)-limit:]
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) listCandles(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	type item struct {
+		Close     decimal.Decimal `json:"close"`
+		High      decimal.Decimal `json:"high"`
+		Low       decimal.Decimal `json:"low"`
+		Open      decimal.Decimal `json:"open"`
+		Precision string          `json:"precision"`
+		Symbol    string          `json:"symbol"`
+		Timestamp int64           `json:"timestamp"`
+		Volume    decimal.Decimal `json:"volume"`
+	}
+
+	q := r.URL.Query()
+	symbol := strings.ToUpper(q.Get("symbol"))
+
+	s.mu.Lock()
+	candles := append([]Candle{}, s.candles[symbol]...)
+	s.mu.Unlock()
+
+	items := []item{}
+	for _, c := range candles {
+		if !inRange(c.Timestamp.Unix(), q.Get("from"), q.Get("to")) {
+			continue
+		}
+		items = append(items, item{
+			Close:     decimal.RequireFromString(orZero(c.Close)),
+			High:      decimal.RequireFromString(orZero(c.High)),
+			Low:       decimal.RequireFromString(orZero(c.Low)),
+			Open:      decimal.RequireFromString(orZero(c.Open)),
+			Precision: q.Get("resolution"),
+			Symbol:    symbol,
+			Timestamp: c.Timestamp.Unix(),
+			Volume:    decimal.RequireFromString(orZero(c.Volume)),
+		})
+	}
+
+	if countback, _ := strconv.Atoi(q.Get("countback")); countback > 0 && len(items) > countback {
+		items = items[len(items)-countback:]
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) listSymbols(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	s.mu.Lock()
+	symbols := s.symbols
+	s.mu.Unlock()
+
+	if len(symbols.Symbol) == 0 {
+		symbols = defaultSymbols()
+	}
+
+	writeJSON(w, http.StatusOK, symbols)
+}
+
+func defaultSymbols() models.SymbolsResponse {
+	return models.SymbolsResponse{
+		Symbol:         []string{"BTC-BRL", "ETH-BRL"},
+		Description:    []string{"Bitcoin", "Ethereum"},
+		Currency:       []string{"BRL", "BRL"},
+		BaseCurrency:   []string{"BTC", "ETH"},
+		ExchangeListed: []bool{true, true},
+		ExchangeTraded: []bool{true, true},
+		Minmovement:    []string{"1", "1"},
+		Pricescale:     []int{100, 100},
+		Type:           []string{"CRYPTO", "CRYPTO"},
+		Timezone:       []string{"America/Sao_Paulo", "America/Sao_Paulo"},
+		SessionRegular: []string{"24x7", "24x7"},
+	}
+}
+
+func (s *Server) listTickers(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	type item struct {
+		Buy  decimal.Decimal `json:"buy"`
+		Date int64           `json:"date"`
+		High decimal.Decimal `json:"high"`
+		Last decimal.Decimal `json:"last"`
+		Low  decimal.Decimal `json:"low"`
+		Open decimal.Decimal `json:"open"`
+		Pair string          `json:"pair"`
+		Sell decimal.Decimal `json:"sell"`
+		Vol  decimal.Decimal `json:"vol"`
+	}
+
+	symbols := strings.Split(strings.ToUpper(r.URL.Query().Get("symbols")), ",")
+	since := time.Now().Add(-24 * time.Hour).Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []item{}
+	for _, symbol := range symbols {
+		if len(symbol) == 0 {
+			continue
+		}
+		if rej := s.checkSymbol(symbol); rej != nil {
+			writeError(w, rej.status, rej.code, rej.message)
+			return
+		}
+
+		t := item{Pair: symbol, Date: time.Now().Unix()}
+		if bids := s.bids[symbol]; len(bids) > 0 {
+			t.Buy = bids[0].LimitPrice
+		}
+		if asks := s.asks[symbol]; len(asks) > 0 {
+			t.Sell = asks[0].LimitPrice
+		}
+		for _, tr := range s.trades[symbol] {
+			t.Last = tr.Price
+			if tr.Date < since {
+				continue
+			}
+			if t.Open.IsZero() {
+				t.Open, t.High, t.Low = tr.Price, tr.Price, tr.Price
+			}
+			t.High = decimal.Max(t.High, tr.Price)
+			t.Low = decimal.Min(t.Low, tr.Price)
+			t.Vol = t.Vol.Add(tr.Amount)
+		}
+		items = append(items, t)
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+// inRange reports whether value is within the optional bounds from and to.
+func inRange(value int64, from, to string) bool {
+	if v, err := strconv.ParseInt(from, 10, 64); err == nil && value < v {
+		return false
+	}
+	if v, err := strconv.ParseInt(to, 10, 64); err == nil && value > v {
+		return false
+	}
+	return true
+}
+
+func splitList(value string) map[string]bool {
+	items := make(map[string]bool)
+	for _, v := range strings.Split(strings.ToUpper(value), ",") {
+		if len(v) > 0 {
+			items[v] = true
+		}
+	}
+	return items
+}
+
+func orZero(value string) string {
+	if len(value) == 0 {
+		return "0"
+	}
+	return value
+}
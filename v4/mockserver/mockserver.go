@@ -0,0 +1,482 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/config"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// baseURL is the host of the endpoints in config.EndPoints, replaced by the
+// server URL when the Api is created with api.OptEndpoint(s.URL()).
+const baseURL = "https://api.mercadobitcoin.net"
+
+const DefaultAccountID = "mock-account"
+
+// methods tells the HTTP method of each endpoint, needed to tell apart the
+// ones sharing a path.
+var methods = map[string]string{
+	"AUTHORIZE":           http.MethodPost,
+	"ACCOUNTS":            http.MethodGet,
+	"BALANCE_LIST":        http.MethodGet,
+	"POSITION_LIST":       http.MethodGet,
+	"ORDER_GET":           http.MethodGet,
+	"ORDER_PLACE":         http.MethodPost,
+	"ORDER_CANCEL":        http.MethodDelete,
+	"ORDER_LIST":          http.MethodGet,
+	"ORDER_CANCEL_ALL":    http.MethodDelete,
+	"WALLET_DEPOSIT":      http.MethodGet,
+	"WALLET_WITHDRAW":     http.MethodPost,
+	"WALLET_GETWITHDRAW":  http.MethodGet,
+	"WALLET_LISTWITHDRAW": http.MethodGet,
+	"ORDERBOOK":           http.MethodGet,
+	"TRADES":              http.MethodGet,
+	"CANDLES":             http.MethodGet,
+	"SYMBOLS":             http.MethodGet,
+	"TICKERS":             http.MethodGet,
+}
+
+type route struct {
+	key      string
+	method   string
+	segments []string
+	literals int
+}
+
+type handler func(w http.ResponseWriter, r *http.Request, vars map[string]string)
+
+// Server is an in-process stand-in for the v4 REST API. It keeps accounts,
+// balances, orders and wallet operations in memory and matches orders with a
+// price-time priority engine. All methods are safe for concurrent use.
+type Server struct {
+	cfg    *ServerCfg
+	srv    *httptest.Server
+	routes []route
+
+	mu           sync.Mutex
+	handlers     map[string]handler
+	tokens       map[string]time.Time
+	balances     map[string]map[string]*balance
+	orders       map[string]*order
+	bids         map[string][]*order
+	asks         map[string][]*order
+	stops        map[string][]*order
+	trades       map[string][]trade
+	deposits     map[string][]Deposit
+	withdrawals  map[int]*withdrawal
+	bankAccounts map[string][]int
+	candles      map[string][]Candle
+	symbols      models.SymbolsResponse
+	failures     map[string][]*Failure
+	calls        map[string]int
+	seq          int64
+}
+
+// New starts a server listening on a local port. Close it when done.
+func New(opts ...Options) (*Server, error) {
+	mts := &ServerCfg{tokenTTL: time.Hour}
+	for _, op := range opts {
+		err := op(mts)
+		if err != nil {
+			return &Server{}, err
+		}
+	}
+
+	if len(mts.accounts) == 0 {
+		mts.accounts = []models.Account{{
+			Currency:     "BRL",
+			CurrencySign: "R$",
+			ID:           DefaultAccountID,
+			Name:         "Mercado Bitcoin",
+			Type:         "live",
+		}}
+	}
+
+	s := &Server{
+		cfg:          mts,
+		tokens:       make(map[string]time.Time),
+		balances:     make(map[string]map[string]*balance),
+		orders:       make(map[string]*order),
+		bids:         make(map[string][]*order),
+		asks:         make(map[string][]*order),
+		stops:        make(map[string][]*order),
+		trades:       make(map[string][]trade),
+		deposits:     make(map[string][]Deposit),
+		withdrawals:  make(map[int]*withdrawal),
+		bankAccounts: make(map[string][]int),
+		candles:      make(map[string][]Candle),
+		failures:     make(map[string][]*Failure),
+		calls:        make(map[string]int),
+		seq:          1000,
+	}
+
+	for _, acc := range mts.accounts {
+		s.balances[acc.ID] = make(map[string]*balance)
+	}
+
+	s.handlers = map[string]handler{
+		"AUTHORIZE":           s.authorize,
+		"ACCOUNTS":            s.private(s.listAccounts),
+		"BALANCE_LIST":        s.private(s.listBalances),
+		"POSITION_LIST":       s.private(s.listPositions),
+		"ORDER_GET":           s.private(s.getOrder),
+		"ORDER_PLACE":         s.private(s.placeOrder),
+		"ORDER_CANCEL":        s.private(s.cancelOrder),
+		"ORDER_LIST":          s.private(s.listOrders),
+		"ORDER_CANCEL_ALL":    s.private(s.cancelAllOrders),
+		"WALLET_DEPOSIT":      s.private(s.listDeposits),
+		"WALLET_WITHDRAW":     s.private(s.withdraw),
+		"WALLET_GETWITHDRAW":  s.private(s.getWithdraw),
+		"WALLET_LISTWITHDRAW": s.private(s.listWithdraws),
+		"ORDERBOOK":           s.orderBook,
+		"TRADES":              s.listTrades,
+		"CANDLES":             s.listCandles,
+		"SYMBOLS":             s.listSymbols,
+		"TICKERS":             s.listTickers,
+	}
+
+	for key, endpoint := range config.EndPoints {
+		method, ok := methods[key]
+		if !ok {
+			continue
+		}
+		segments := strings.Split(strings.Trim(strings.TrimPrefix(endpoint, baseURL), "/"), "/")
+		literals := 0
+		for _, seg := range segments {
+			if !isVar(seg) {
+				literals++
+			}
+		}
+		s.routes = append(s.routes, route{key: key, method: method, segments: segments, literals: literals})
+	}
+
+	// Paths with more fixed segments win, so /wallet/{symbol}/deposits is not
+	// taken for /{symbol}/orders/{orderId}.
+	sort.Slice(s.routes, func(i, j int) bool {
+		if s.routes[i].literals != s.routes[j].literals {
+			return s.routes[i].literals > s.routes[j].literals
+		}
+		return s.routes[i].key < s.routes[j].key
+	})
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s, nil
+}
+
+// URL is the value to pass to api.OptEndpoint.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+func (s *Server) Client() *http.Client {
+	return s.srv.Client()
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Fail scripts the next calls to the endpoint named key in config.EndPoints.
+// Failures queue up and are used in order.
+func (s *Server) Fail(key string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f.Times <= 0 {
+		f.Times = 1
+	}
+	s.failures[key] = append(s.failures[key], &f)
+}
+
+// Calls returns how many requests reached the endpoint named key.
+func (s *Server) Calls(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[key]
+}
+
+// ExpireTokens invalidates every token issued, so the next private call
+// answers 401.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]time.Time)
+}
+
+// SetBalance sets the available amount of asset in the account.
+func (s *Server) SetBalance(accountID, asset, available string) error {
+	amount, err := decimal.NewFromString(available)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.wallet(accountID, strings.ToUpper(asset)).available = amount
+	return nil
+}
+
+// Balance returns the available and on hold amounts of asset in the account.
+func (s *Server) Balance(accountID, asset string) (available, onHold decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.wallet(accountID, strings.ToUpper(asset))
+	return b.available, b.onHold
+}
+
+// AddDeposit lists d in the deposits of symbol. A deposit with status
+// "confirmed" credits the account.
+func (s *Server) AddDeposit(accountID, symbol string, d Deposit) error {
+	amount, err := decimal.NewFromString(d.Amount)
+	if err != nil {
+		return err
+	}
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	symbol = strings.ToUpper(symbol)
+	s.deposits[accountID+"|"+symbol] = append(s.deposits[accountID+"|"+symbol], d)
+	if d.Status == "confirmed" {
+		b := s.wallet(accountID, symbol)
+		b.available = b.available.Add(amount)
+	}
+	return nil
+}
+
+// AddBankAccount registers a bank account or PIX key as a destination of BRL
+// withdrawals and returns its account_ref.
+func (s *Server) AddBankAccount(accountID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ref := int(s.nextID())
+	s.bankAccounts[accountID] = append(s.bankAccounts[accountID], ref)
+	return ref
+}
+
+// SetWithdrawStatus moves a withdrawal to status, 1 open, 2 done or 3
+// cancelled, with the transaction hash tx. A cancelled withdrawal gives the
+// quantity back to the account.
+func (s *Server) SetWithdrawStatus(id, status int, tx string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wd, ok := s.withdrawals[id]
+	if !ok {
+		return fmt.Errorf("withdraw %d not found", id)
+	}
+
+	if status == 3 && wd.Status != 3 {
+		b := s.wallet(wd.Account, wd.Coin)
+		b.available = b.available.Add(wd.Quantity)
+	}
+	wd.Status = status
+	wd.Tx = tx
+	wd.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return nil
+}
+
+// SetCandles replaces the candles served for symbol.
+func (s *Server) SetCandles(symbol string, candles []Candle) error {
+	for _, c := range candles {
+		for _, v := range []string{c.Open, c.High, c.Low, c.Close, c.Volume} {
+			if _, err := decimal.NewFromString(orZero(v)); err != nil {
+				return fmt.Errorf("invalid candle at %s: %w", c.Timestamp, err)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.candles[strings.ToUpper(symbol)] = candles
+	return nil
+}
+
+// SetSymbols replaces the answer of the symbols endpoint. When set, orders
+// on symbols not listed are rejected.
+func (s *Server) SetSymbols(symbols models.SymbolsResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.symbols = symbols
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	rt, vars, ok := s.lookup(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "API|NOT_FOUND", fmt.Sprintf("%s %s not found", r.Method, r.URL.Path))
+		return
+	}
+
+	s.mu.Lock()
+	s.calls[rt.key]++
+	failure := s.nextFailure(rt.key)
+	h := s.handlers[rt.key]
+	s.mu.Unlock()
+
+	if failure == nil {
+		h(w, r, vars)
+		return
+	}
+
+	if failure.Delay > 0 {
+		select {
+		case <-time.After(failure.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if failure.Process {
+		h(httptest.NewRecorder(), r, vars)
+	}
+
+	if failure.Drop {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+
+	if len(failure.RetryAfter) > 0 {
+		w.Header().Set("Retry-After", failure.RetryAfter)
+	}
+
+	if failure.Status == 0 {
+		if !failure.Process {
+			h(w, r, vars)
+		}
+		return
+	}
+
+	writeError(w, failure.Status, failure.Code, failure.Message)
+}
+
+func (s *Server) lookup(r *http.Request) (route, map[string]string, bool) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	for _, rt := range s.routes {
+		if rt.method != r.Method || len(rt.segments) != len(segments) {
+			continue
+		}
+
+		vars := make(map[string]string)
+		matched := true
+		for i, seg := range rt.segments {
+			if isVar(seg) {
+				vars[strings.Trim(seg, "{}")] = segments[i]
+				continue
+			}
+			if seg != segments[i] {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return rt, vars, true
+		}
+	}
+
+	return route{}, nil, false
+}
+
+func (s *Server) nextFailure(key string) *Failure {
+	queue := s.failures[key]
+	if len(queue) == 0 {
+		return nil
+	}
+
+	f := queue[0]
+	f.Times--
+	if f.Times <= 0 {
+		s.failures[key] = queue[1:]
+	}
+	return f
+}
+
+// private rejects requests without a valid token issued by authorize.
+func (s *Server) private(h handler) handler {
+	return func(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		expiration, ok := s.tokens[token]
+		s.mu.Unlock()
+
+		if !ok || time.Now().After(expiration) {
+			writeError(w, http.StatusUnauthorized, "API|UNAUTHORIZED", "invalid or expired token")
+			return
+		}
+
+		if id, ok := vars["accountId"]; ok && !s.hasAccount(id) {
+			writeError(w, http.StatusNotFound, "API|ACCOUNT_NOT_FOUND", fmt.Sprintf("account %s not found", id))
+			return
+		}
+
+		h(w, r, vars)
+	}
+}
+
+func (s *Server) hasAccount(id string) bool {
+	for _, acc := range s.cfg.accounts {
+		if acc.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// wallet returns the balance of asset in the account, creating it empty.
+// Callers hold s.mu.
+func (s *Server) wallet(accountID, asset string) *balance {
+	assets, ok := s.balances[accountID]
+	if !ok {
+		assets = make(map[string]*balance)
+		s.balances[accountID] = assets
+	}
+	b, ok := assets[asset]
+	if !ok {
+		b = &balance{}
+		assets[asset] = b
+	}
+	return b
+}
+
+func (s *Server) nextID() int64 {
+	s.seq++
+	return s.seq
+}
+
+func isVar(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	if len(message) == 0 {
+		message = http.StatusText(status)
+	}
+	writeJSON(w, status, models.ErrorApiResponse{Code: code, Message: message})
+}
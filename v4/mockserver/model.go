@@ -0,0 +1,184 @@
+package mockserver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+type Options func(o *ServerCfg) error
+
+type ServerCfg struct {
+	login    string
+	password string
+	accounts []models.Account
+	makerFee decimal.Decimal
+	takerFee decimal.Decimal
+	tokenTTL time.Duration
+	listLag  time.Duration
+}
+
+// OptCredentials only accepts logins with this key and secret. Without it any
+// credentials are accepted.
+func OptCredentials(login, password string) Options {
+	return func(o *ServerCfg) error {
+		o.login = login
+		o.password = password
+		return nil
+	}
+}
+
+// OptAccounts replaces the default account returned by /accounts.
+func OptAccounts(accounts ...models.Account) Options {
+	return func(o *ServerCfg) error {
+		o.accounts = append(o.accounts, accounts...)
+		return nil
+	}
+}
+
+// OptFees sets the maker and taker fee rates, e.g. "0.003" for 0.3%. The fee
+// is charged on the asset received.
+func OptFees(maker, taker string) Options {
+	return func(o *ServerCfg) error {
+		m, err := decimal.NewFromString(maker)
+		if err != nil {
+			return err
+		}
+		t, err := decimal.NewFromString(taker)
+		if err != nil {
+			return err
+		}
+		o.makerFee = m
+		o.takerFee = t
+		return nil
+	}
+}
+
+func OptTokenTTL(ttl time.Duration) Options {
+	return func(o *ServerCfg) error {
+		o.tokenTTL = ttl
+		return nil
+	}
+}
+
+// OptListLag hides the orders placed through the API from the order list
+// until lag after they were accepted, as an exchange whose list lags behind
+// its matching engine.
+func OptListLag(lag time.Duration) Options {
+	return func(o *ServerCfg) error {
+		if lag < 0 {
+			return fmt.Errorf("invalid list lag %s", lag)
+		}
+		o.listLag = lag
+		return nil
+	}
+}
+
+// Failure scripts the answer of the next calls to an endpoint, identified by
+// its config.EndPoints name.
+type Failure struct {
+	// Status and Code build an error answer like the exchange ones.
+	Status  int
+	Code    string
+	Message string
+	// RetryAfter is sent as the Retry-After header when not empty.
+	RetryAfter string
+	// Delay holds the answer, useful to trigger client timeouts.
+	Delay time.Duration
+	// Drop closes the connection without answering.
+	Drop bool
+	// Process handles the request before failing, as when the exchange
+	// accepted it but the answer was lost.
+	Process bool
+	// Times is the number of calls affected, one when zero.
+	Times int
+}
+
+// Deposit is a deposit listed by the wallet deposits endpoint.
+type Deposit struct {
+	Address      string
+	AddressTag   string
+	Amount       string
+	ConfirmTimes string
+	CreatedAt    time.Time
+	Status       string
+	TransferType string
+}
+
+// Candle is served by the candles endpoint for its symbol.
+type Candle struct {
+	Timestamp time.Time
+	Open      string
+	High      string
+	Low       string
+	Close     string
+	Volume    string
+}
+
+type balance struct {
+	available decimal.Decimal
+	onHold    decimal.Decimal
+}
+
+type execution struct {
+	ExecutedAt int64           `json:"executed_at"`
+	FeeRate    decimal.Decimal `json:"fee_rate"`
+	ID         string          `json:"id"`
+	Instrument string          `json:"instrument"`
+	Price      decimal.Decimal `json:"price"`
+	Qty        decimal.Decimal `json:"qty"`
+	Side       string          `json:"side"`
+}
+
+type order struct {
+	AvgPrice       decimal.Decimal `json:"avgPrice"`
+	CreatedAt      int64           `json:"created_at"`
+	Executions     []execution     `json:"executions"`
+	ExternalID     string          `json:"externalId,omitempty"`
+	Fee            decimal.Decimal `json:"fee"`
+	FilledQty      decimal.Decimal `json:"filledQty"`
+	ID             string          `json:"id"`
+	Instrument     string          `json:"instrument"`
+	LimitPrice     decimal.Decimal `json:"limitPrice"`
+	Qty            decimal.Decimal `json:"qty"`
+	Side           string          `json:"side"`
+	Status         string          `json:"status"`
+	StopPrice      decimal.Decimal `json:"stopPrice"`
+	TriggerOrderID string          `json:"triggerOrderId"`
+	Type           string          `json:"type"`
+	UpdatedAt      int64           `json:"updated_at"`
+
+	account  string
+	seq      int64
+	cost     decimal.Decimal
+	accepted time.Time
+	// held is what is still reserved for the order: quote for buys, base for
+	// sells.
+	held decimal.Decimal
+}
+
+type trade struct {
+	Amount decimal.Decimal `json:"amount"`
+	Date   int64           `json:"date"`
+	Price  decimal.Decimal `json:"price"`
+	Tid    int64           `json:"tid"`
+	Type   string          `json:"type"`
+}
+
+type withdrawal struct {
+	Account     string          `json:"account"`
+	Address     string          `json:"address"`
+	Coin        string          `json:"coin"`
+	CreatedAt   string          `json:"created_at"`
+	Description string          `json:"description"`
+	Fee         decimal.Decimal `json:"fee"`
+	ID          int             `json:"id"`
+	NetQuantity decimal.Decimal `json:"net_quantity"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	Status      int             `json:"status"`
+	Tx          string          `json:"tx"`
+	UpdatedAt   string          `json:"updated_at"`
+	AccountRef  int             `json:"account_ref,omitempty"`
+}
@@ -0,0 +1,217 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// StreamServer is a local stand-in of the WebSocket feed, for the stream
+// package. It keeps the subscriptions of each connection, answers pings and
+// broadcasts the messages given to Send.
+type StreamServer struct {
+	srv      *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	conns    map[*websocket.Conn]*streamConn
+	requests []models.StreamRequest
+	dials    []time.Time
+	refuse   int
+}
+
+type streamConn struct {
+	writeMu sync.Mutex
+	subs    map[string]models.StreamSubscription
+}
+
+// NewStream starts a feed listening on a local port. Close it when done.
+func NewStream() *StreamServer {
+	s := &StreamServer{conns: make(map[*websocket.Conn]*streamConn)}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveWS))
+	return s
+}
+
+// URL is the value to pass to stream.OptEndpoint.
+func (s *StreamServer) URL() string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http")
+}
+
+func (s *StreamServer) Close() {
+	s.Drop()
+	s.srv.Close()
+}
+
+// Refuse answers the next n connection attempts with 503.
+func (s *StreamServer) Refuse(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refuse = n
+}
+
+// Drop closes every open connection, as when the exchange goes away.
+func (s *StreamServer) Drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Dials returns the time of every connection attempt, refused ones included.
+func (s *StreamServer) Dials() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time{}, s.dials...)
+}
+
+// Connections returns the number of open connections.
+func (s *StreamServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// Requests returns every request received, pings included.
+func (s *StreamServer) Requests() []models.StreamRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.StreamRequest{}, s.requests...)
+}
+
+// Subscriptions returns the subscriptions of the open connections, sorted by
+// name and id.
+func (s *StreamServer) Subscriptions() []models.StreamSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := []models.StreamSubscription{}
+	for _, c := range s.conns {
+		for _, sub := range c.subs {
+			subs = append(subs, sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Name != subs[j].Name {
+			return subs[i].Name < subs[j].Name
+		}
+		return subs[i].ID < subs[j].ID
+	})
+	return subs
+}
+
+// Send broadcasts data on channel for the feed id, e.g. BRLBTC, to the
+// connections subscribed to it.
+func (s *StreamServer) Send(channel, id string, data interface{}) error {
+	bts, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	msg := models.StreamMessage{Type: channel, ID: id, TS: time.Now().UnixNano(), Data: bts}
+	return s.broadcast(msg, func(c *streamConn) bool {
+		_, ok := c.subs[channel+"|"+id]
+		return ok
+	})
+}
+
+// SendError broadcasts an error message to every connection.
+func (s *StreamServer) SendError(message string) error {
+	return s.broadcast(models.StreamMessage{Type: "error", Message: message}, func(*streamConn) bool { return true })
+}
+
+func (s *StreamServer) broadcast(msg models.StreamMessage, match func(*streamConn) bool) error {
+	s.mu.Lock()
+	targets := map[*websocket.Conn]*streamConn{}
+	for conn, c := range s.conns {
+		if match(c) {
+			targets[conn] = c
+		}
+	}
+	s.mu.Unlock()
+
+	var errs []string
+	for conn, c := range targets {
+		if err := c.write(conn, msg); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("send %s: %s", msg.Type, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c *streamConn) write(conn *websocket.Conn, v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return conn.WriteJSON(v)
+}
+
+func (s *StreamServer) serveWS(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.dials = append(s.dials, time.Now())
+	refused := s.refuse > 0
+	if refused {
+		s.refuse--
+	}
+	s.mu.Unlock()
+
+	if refused {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	c := &streamConn{subs: make(map[string]models.StreamSubscription)}
+
+	s.mu.Lock()
+	s.conns[conn] = c
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	for {
+		req := models.StreamRequest{}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		if req.Subscription != nil {
+			k := req.Subscription.Name + "|" + req.Subscription.ID
+			switch req.Type {
+			case "subscribe":
+				c.subs[k] = *req.Subscription
+			case "unsubscribe":
+				delete(c.subs, k)
+			}
+		}
+		s.mu.Unlock()
+
+		if req.Type == "ping" {
+			if err := c.write(conn, models.StreamMessage{Type: "pong"}); err != nil {
+				return
+			}
+		}
+	}
+}
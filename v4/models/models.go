@@ -1,6 +1,13 @@
 package models
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type AuthoritionToken struct {
 	AccessToken string `json:"access_token"`
@@ -20,32 +27,64 @@ type TickersQuery struct {
 }
 
 type TickersResponse []struct {
-	Buy  string `json:"buy"`
-	Date int    `json:"date"`
-	High string `json:"high"`
-	Last string `json:"last"`
-	Low  string `json:"low"`
-	Open string `json:"open"`
-	Pair string `json:"pair"`
-	Sell string `json:"sell"`
-	Vol  string `json:"vol"`
+	Buy  decimal.Decimal `json:"buy"`
+	Date int             `json:"date"`
+	High decimal.Decimal `json:"high"`
+	Last decimal.Decimal `json:"last"`
+	Low  decimal.Decimal `json:"low"`
+	Open decimal.Decimal `json:"open"`
+	Pair string          `json:"pair"`
+	Sell decimal.Decimal `json:"sell"`
+	Vol  decimal.Decimal `json:"vol"`
 }
 
-type ListBalancesResponse []struct {
-	Available string `json:"available"`
-	OnHold    string `json:"on_hold"`
-	Symbol    string `json:"symbol"`
-	Total     string `json:"total"`
+type ListBalancesResponse []Balance
+
+type Balance struct {
+	Available decimal.Decimal `json:"available"`
+	OnHold    decimal.Decimal `json:"on_hold"`
+	Symbol    string          `json:"symbol"`
+	Total     decimal.Decimal `json:"total"`
 }
 
 type PlaceOrderPayload struct {
-	Async      bool   `json:"async,omitempty"`
-	Cost       int    `json:"cost,omitempty"`
-	LimitPrice int    `json:"limitPrice,omitempty"`
-	Qty        string `json:"qty,omitempty"`
-	Side       string `json:"side,omitempty"`
-	StopPrice  int    `json:"stopPrice,omitempty"`
-	Type       string `json:"type,omitempty"`
+	Async      bool            `json:"async,omitempty"`
+	Cost       decimal.Decimal `json:"cost,omitempty"`
+	LimitPrice decimal.Decimal `json:"limitPrice,omitempty"`
+	Qty        decimal.Decimal `json:"qty,omitempty"`
+	Side       string          `json:"side,omitempty"`
+	StopPrice  decimal.Decimal `json:"stopPrice,omitempty"`
+	Type       string          `json:"type,omitempty"`
+	ExternalID string          `json:"externalId,omitempty"`
+}
+
+// MarshalJSON sends cost, limitPrice and stopPrice as JSON numbers and qty as
+// a string, as expected by the API, leaving out the zero values.
+func (p PlaceOrderPayload) MarshalJSON() ([]byte, error) {
+	payload := struct {
+		Async      bool         `json:"async,omitempty"`
+		Cost       *json.Number `json:"cost,omitempty"`
+		LimitPrice *json.Number `json:"limitPrice,omitempty"`
+		Qty        string       `json:"qty,omitempty"`
+		Side       string       `json:"side,omitempty"`
+		StopPrice  *json.Number `json:"stopPrice,omitempty"`
+		Type       string       `json:"type,omitempty"`
+		ExternalID string       `json:"externalId,omitempty"`
+	}{
+		Async:      p.Async,
+		Cost:       decimalNumber(p.Cost),
+		LimitPrice: decimalNumber(p.LimitPrice),
+		Side:       p.Side,
+		StopPrice:  decimalNumber(p.StopPrice),
+		Type:       p.Type,
+		ExternalID: p.ExternalID,
+	}
+
+	if !p.Qty.IsZero() {
+		payload.Qty = p.Qty.String()
+	}
+
+	return json.Marshal(payload)
 }
 
 func (p *PlaceOrderPayload) ToBytes() []byte {
@@ -68,7 +107,9 @@ func (p *PlaceOrderResponse) ToBytes() []byte {
 	return bts
 }
 
-type ListAccountsResponse []struct {
+type ListAccountsResponse []Account
+
+type Account struct {
 	Currency     string `json:"currency"`
 	CurrencySign string `json:"currencySign"`
 	ID           string `json:"id"`
@@ -98,41 +139,60 @@ type CustomPlaceOrderInfo struct {
 	Error      error  `json:"error"`
 }
 
+// PlaceOrderResult describes a submitted order. Reconciled is set when the
+// response was lost and the order was found afterwards by its external id.
+type PlaceOrderResult struct {
+	OrderID    string            `json:"order_id"`
+	ExternalID string            `json:"external_id"`
+	Reconciled bool              `json:"reconciled"`
+	StatusCode int               `json:"status_code"`
+	EndPoint   string            `json:"endpoint"`
+	Payload    PlaceOrderPayload `json:"payload"`
+	Response   string            `json:"response"`
+}
+
+type ListPositionQuery struct {
+	Symbols string `url:"symbols,omitempty"`
+}
+
 type ListPositionResponse []struct {
-	AvgPrice   int    `json:"avgPrice"`
-	Category   string `json:"category"`
-	ID         string `json:"id"`
-	Instrument string `json:"instrument"`
-	Qty        string `json:"qty"`
-	Side       string `json:"side"`
+	AvgPrice   decimal.Decimal `json:"avgPrice"`
+	Category   string          `json:"category"`
+	ID         string          `json:"id"`
+	Instrument string          `json:"instrument"`
+	Qty        decimal.Decimal `json:"qty"`
+	Side       string          `json:"side"`
 }
 
 type ListOrderResponse []GetOrderResponse
 
 type GetOrderResponse struct {
-	AvgPrice   int `json:"avgPrice"`
-	CreatedAt  int `json:"created_at"`
-	Executions []struct {
-		ExecutedAt int    `json:"executed_at"`
-		FeeRate    string `json:"fee_rate"`
-		ID         string `json:"id"`
-		Instrument string `json:"instrument"`
-		Price      int    `json:"price"`
-		Qty        string `json:"qty"`
-		Side       string `json:"side"`
-	} `json:"executions"`
-	Fee            string `json:"fee"`
-	FilledQty      string `json:"filledQty"`
-	ID             string `json:"id"`
-	Instrument     string `json:"instrument"`
-	LimitPrice     int    `json:"limitPrice"`
-	Qty            string `json:"qty"`
-	Side           string `json:"side"`
-	Status         string `json:"status"`
-	StopPrice      int    `json:"stopPrice"`
-	TriggerOrderID string `json:"triggerOrderId"`
-	Type           string `json:"type"`
-	UpdatedAt      int    `json:"updated_at"`
+	AvgPrice       decimal.Decimal  `json:"avgPrice"`
+	CreatedAt      int              `json:"created_at"`
+	Executions     []OrderExecution `json:"executions"`
+	ExternalID     string           `json:"externalId"`
+	Fee            decimal.Decimal  `json:"fee"`
+	FilledQty      decimal.Decimal  `json:"filledQty"`
+	ID             string           `json:"id"`
+	Instrument     string           `json:"instrument"`
+	LimitPrice     decimal.Decimal  `json:"limitPrice"`
+	Qty            decimal.Decimal  `json:"qty"`
+	Side           string           `json:"side"`
+	Status         string           `json:"status"`
+	StopPrice      decimal.Decimal  `json:"stopPrice"`
+	TriggerOrderID string           `json:"triggerOrderId"`
+	Type           string           `json:"type"`
+	UpdatedAt      int              `json:"updated_at"`
+}
+
+type OrderExecution struct {
+	ExecutedAt int             `json:"executed_at"`
+	FeeRate    decimal.Decimal `json:"fee_rate"`
+	ID         string          `json:"id"`
+	Instrument string          `json:"instrument"`
+	Price      decimal.Decimal `json:"price"`
+	Qty        decimal.Decimal `json:"qty"`
+	Side       string          `json:"side"`
 }
 
 type OrdersIndex struct {
@@ -149,17 +209,27 @@ type OrderBookQuery struct {
 	Limit string `url:"limit,omitempty"`
 }
 type OrderBookResponse struct {
-	Asks      []string `json:"asks"`
-	Bids      []string `json:"bids"`
-	Timestamp int      `json:"timestamp"`
+	Asks      []OrderBookLevel `json:"asks"`
+	Bids      []OrderBookLevel `json:"bids"`
+	Timestamp int              `json:"timestamp"`
+}
+
+// OrderBookDelta is an incremental update of the order book. A level with zero
+// quantity removes the price from the book.
+type OrderBookDelta struct {
+	Symbol    string           `json:"symbol"`
+	Sequence  int64            `json:"sequence"`
+	Timestamp int64            `json:"timestamp"`
+	Asks      []OrderBookLevel `json:"asks"`
+	Bids      []OrderBookLevel `json:"bids"`
 }
 
 type TradesResponse []struct {
-	Amount string `json:"amount"`
-	Date   int    `json:"date"`
-	Price  string `json:"price"`
-	Tid    int    `json:"tid"`
-	Type   string `json:"type"`
+	Amount decimal.Decimal `json:"amount"`
+	Date   int             `json:"date"`
+	Price  decimal.Decimal `json:"price"`
+	Tid    int             `json:"tid"`
+	Type   string          `json:"type"`
 }
 
 type CandlesQuery struct {
@@ -171,14 +241,38 @@ type CandlesQuery struct {
 }
 
 type CandlesResponse []struct {
-	Close     string `json:"close"`
-	High      string `json:"high"`
-	Low       string `json:"low"`
-	Open      string `json:"open"`
-	Precision string `json:"precision"`
-	Symbol    string `json:"symbol"`
-	Timestamp int    `json:"timestamp"`
-	Volume    string `json:"volume"`
+	Close     decimal.Decimal `json:"close"`
+	High      decimal.Decimal `json:"high"`
+	Low       decimal.Decimal `json:"low"`
+	Open      decimal.Decimal `json:"open"`
+	Precision string          `json:"precision"`
+	Symbol    string          `json:"symbol"`
+	Timestamp int             `json:"timestamp"`
+	Volume    decimal.Decimal `json:"volume"`
+}
+
+// Bar is an OHLCV candle opened at Time.
+type Bar struct {
+	Time   time.Time       `json:"time"`
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Volume decimal.Decimal `json:"volume"`
+}
+
+// Gap is a run of missing bars, From and To being the open times of the first
+// and last missing ones.
+type Gap struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type CandleHistory struct {
+	Symbol     string `json:"symbol"`
+	Resolution string `json:"resolution"`
+	Bars       []Bar  `json:"bars"`
+	Gaps       []Gap  `json:"gaps"`
 }
 
 type SymbolsQuery struct {
@@ -199,44 +293,210 @@ type SymbolsResponse struct {
 	SessionRegular []string `json:"session-regular"`
 }
 
+// SymbolInfo is the record of one instrument of SymbolsResponse. TickSize is
+// minmovement / pricescale and PricePrecision its number of decimals. The
+// response has no quantity step.
+type SymbolInfo struct {
+	Symbol         string          `json:"symbol"`
+	Description    string          `json:"description"`
+	Currency       string          `json:"currency"`
+	BaseCurrency   string          `json:"base_currency"`
+	ExchangeListed bool            `json:"exchange_listed"`
+	ExchangeTraded bool            `json:"exchange_traded"`
+	Minmovement    decimal.Decimal `json:"minmovement"`
+	Pricescale     int             `json:"pricescale"`
+	Type           string          `json:"type"`
+	Timezone       string          `json:"timezone"`
+	SessionRegular string          `json:"session_regular"`
+	TickSize       decimal.Decimal `json:"tick_size"`
+	PricePrecision int32           `json:"price_precision"`
+}
+
+// Records transposes the parallel slices of the response into one record per
+// symbol, in the order of Symbol. Missing columns leave zero values.
+func (s SymbolsResponse) Records() ([]SymbolInfo, error) {
+	records := make([]SymbolInfo, 0, len(s.Symbol))
+
+	for i, symbol := range s.Symbol {
+		info := SymbolInfo{
+			Symbol:         symbol,
+			Description:    column(s.Description, i),
+			Currency:       column(s.Currency, i),
+			BaseCurrency:   column(s.BaseCurrency, i),
+			Type:           column(s.Type, i),
+			Timezone:       column(s.Timezone, i),
+			SessionRegular: column(s.SessionRegular, i),
+		}
+		if i < len(s.ExchangeListed) {
+			info.ExchangeListed = s.ExchangeListed[i]
+		}
+		if i < len(s.ExchangeTraded) {
+			info.ExchangeTraded = s.ExchangeTraded[i]
+		}
+		if i < len(s.Pricescale) {
+			info.Pricescale = s.Pricescale[i]
+		}
+
+		if minmovement := column(s.Minmovement, i); len(minmovement) > 0 {
+			value, err := decimal.NewFromString(minmovement)
+			if err != nil {
+				return records, fmt.Errorf("invalid minmovement %q for %s: %w", minmovement, symbol, err)
+			}
+			info.Minmovement = value
+		}
+
+		if info.Pricescale > 0 && info.Minmovement.IsPositive() {
+			// the division keeps 16 decimals, parsing the string drops the
+			// trailing zeros
+			tick := info.Minmovement.Div(decimal.NewFromInt(int64(info.Pricescale)))
+			info.TickSize = decimal.RequireFromString(tick.String())
+			if exp := info.TickSize.Exponent(); exp < 0 {
+				info.PricePrecision = -exp
+			}
+		}
+
+		records = append(records, info)
+	}
+
+	return records, nil
+}
+
+// BySymbol indexes the records by upper case symbol.
+func (s SymbolsResponse) BySymbol() (map[string]SymbolInfo, error) {
+	records, err := s.Records()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]SymbolInfo, len(records))
+	for _, info := range records {
+		index[strings.ToUpper(info.Symbol)] = info
+	}
+	return index, nil
+}
+
+func column(values []string, i int) string {
+	if i < len(values) {
+		return values[i]
+	}
+	return ""
+}
+
 type ErrorApiResponse struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 }
 
-type WalletGetDepositsResponse []struct {
-	Address      string `json:"address"`
-	AddressTag   string `json:"addressTag"`
-	Amount       string `json:"amount"`
-	Coin         string `json:"coin"`
-	ConfirmTimes string `json:"confirmTimes"`
-	CreatedAt    int    `json:"createdAt"`
-	Status       string `json:"status"`
-	TransferType string `json:"transferType"`
+type WalletGetDepositsResponse []Deposit
+
+type Deposit struct {
+	Address      string          `json:"address"`
+	AddressTag   string          `json:"addressTag"`
+	Amount       decimal.Decimal `json:"amount"`
+	Coin         string          `json:"coin"`
+	ConfirmTimes string          `json:"confirmTimes"`
+	CreatedAt    int             `json:"createdAt"`
+	Status       string          `json:"status"`
+	TransferType string          `json:"transferType"`
 }
 
 type WalletWithdrawCoinResponse struct {
-	Account     string `json:"account"`
-	Address     string `json:"address"`
-	Coin        string `json:"coin"`
-	CreatedAt   string `json:"created_at"`
-	Description string `json:"description"`
-	Fee         string `json:"fee"`
-	ID          int    `json:"id"`
-	NetQuantity string `json:"net_quantity"`
-	Quantity    string `json:"quantity"`
-	Status      int    `json:"status"`
-	Tx          string `json:"tx"`
-	UpdatedAt   string `json:"updated_at"`
+	Account     string          `json:"account"`
+	Address     string          `json:"address"`
+	Coin        string          `json:"coin"`
+	CreatedAt   string          `json:"created_at"`
+	Description string          `json:"description"`
+	Fee         decimal.Decimal `json:"fee"`
+	ID          int             `json:"id"`
+	NetQuantity decimal.Decimal `json:"net_quantity"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	Status      int             `json:"status"`
+	Tx          string          `json:"tx"`
+	UpdatedAt   string          `json:"updated_at"`
+	// AccountRef is the bank account or PIX key of BRL withdrawals.
+	AccountRef int `json:"account_ref,omitempty"`
+}
+
+type WalletWithdrawListResponse []WalletWithdrawCoinResponse
+
+// WithdrawStatus is the stage of a coin withdrawal, see
+// WalletWithdrawCoinResponse.WithdrawStatus.
+type WithdrawStatus int
+
+const (
+	WithdrawUnknown WithdrawStatus = iota
+	WithdrawPending
+	WithdrawProcessing
+	WithdrawSent
+	WithdrawFailed
+)
+
+func (s WithdrawStatus) String() string {
+	return [...]string{"unknown", "pending", "processing", "sent", "failed"}[s]
+}
+
+// Terminal reports whether the withdrawal can no longer change.
+func (s WithdrawStatus) Terminal() bool {
+	return s == WithdrawSent || s == WithdrawFailed
+}
+
+// WithdrawStatus maps the status code of the exchange, 1 open, 2 done and
+// 3 cancelled, to a WithdrawStatus. An open withdrawal is processing once it
+// has a transaction hash. A done one is sent, with or without the hash, which
+// may come later or never, as for BRL withdrawals.
+func (w WalletWithdrawCoinResponse) WithdrawStatus() WithdrawStatus {
+	switch w.Status {
+	case 1:
+		if len(w.Tx) > 0 {
+			return WithdrawProcessing
+		}
+		return WithdrawPending
+	case 2:
+		return WithdrawSent
+	case 3:
+		return WithdrawFailed
+	}
+	return WithdrawUnknown
 }
 
 type WalletWithdrawCoinPayload struct {
-	AccountRef  int    `json:"account_ref"`
-	Address     string `json:"address"`
-	Description string `json:"description"`
-	Quantity    string `json:"quantity"`
-	Symbol      string `json:"symbol"`
-	TxFee       string `json:"tx_fee"`
+	AccountRef     int             `json:"account_ref"`
+	Address        string          `json:"address"`
+	DestinationTag string          `json:"destination_tag,omitempty"`
+	Network        string          `json:"network,omitempty"`
+	Description    string          `json:"description"`
+	Quantity       decimal.Decimal `json:"quantity"`
+	Symbol         string          `json:"symbol"`
+	TxFee          decimal.Decimal `json:"tx_fee"`
+}
+
+// MarshalJSON sends quantity and tx_fee as strings, leaving tx_fee empty when
+// it is not set so the exchange applies its default fee.
+func (p WalletWithdrawCoinPayload) MarshalJSON() ([]byte, error) {
+	payload := struct {
+		AccountRef     int    `json:"account_ref"`
+		Address        string `json:"address"`
+		DestinationTag string `json:"destination_tag,omitempty"`
+		Network        string `json:"network,omitempty"`
+		Description    string `json:"description"`
+		Quantity       string `json:"quantity"`
+		Symbol         string `json:"symbol"`
+		TxFee          string `json:"tx_fee"`
+	}{
+		AccountRef:     p.AccountRef,
+		Address:        p.Address,
+		DestinationTag: p.DestinationTag,
+		Network:        p.Network,
+		Description:    p.Description,
+		Quantity:       p.Quantity.String(),
+		Symbol:         p.Symbol,
+	}
+
+	if !p.TxFee.IsZero() {
+		payload.TxFee = p.TxFee.String()
+	}
+
+	return json.Marshal(payload)
 }
 
 func (p *WalletWithdrawCoinPayload) ToBytes() []byte {
@@ -246,3 +506,123 @@ func (p *WalletWithdrawCoinPayload) ToBytes() []byte {
 	}
 	return bts
 }
+
+// WalletWithdrawFiatPayload is a BRL withdrawal to the registered bank
+// account or PIX key AccountRef.
+type WalletWithdrawFiatPayload struct {
+	AccountRef  int             `json:"account_ref"`
+	Description string          `json:"description"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	Symbol      string          `json:"symbol"`
+}
+
+// MarshalJSON sends quantity as a string.
+func (p WalletWithdrawFiatPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		AccountRef  int    `json:"account_ref"`
+		Description string `json:"description"`
+		Quantity    string `json:"quantity"`
+		Symbol      string `json:"symbol"`
+	}{
+		AccountRef:  p.AccountRef,
+		Description: p.Description,
+		Quantity:    p.Quantity.String(),
+		Symbol:      p.Symbol,
+	})
+}
+
+func (p *WalletWithdrawFiatPayload) ToBytes() []byte {
+	bts, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	return bts
+}
+
+func decimalNumber(value decimal.Decimal) *json.Number {
+	if value.IsZero() {
+		return nil
+	}
+	n := json.Number(value.String())
+	return &n
+}
+
+// WithdrawPreview is the request a withdrawal would send, as built by a dry
+// run.
+type WithdrawPreview struct {
+	Method   string          `json:"method"`
+	Endpoint string          `json:"endpoint"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+type StreamSubscription struct {
+	Name  string `json:"name"`
+	ID    string `json:"id"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+type StreamRequest struct {
+	Type         string              `json:"type"`
+	Subscription *StreamSubscription `json:"subscription,omitempty"`
+}
+
+type StreamMessage struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id"`
+	TS      int64           `json:"ts"`
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+}
+
+type StreamTicker struct {
+	Symbol    string          `json:"symbol"`
+	Timestamp int64           `json:"ts"`
+	Buy       decimal.Decimal `json:"buy"`
+	Date      int64           `json:"date"`
+	High      decimal.Decimal `json:"high"`
+	Last      decimal.Decimal `json:"last"`
+	Low       decimal.Decimal `json:"low"`
+	Open      decimal.Decimal `json:"open"`
+	Sell      decimal.Decimal `json:"sell"`
+	Vol       decimal.Decimal `json:"vol"`
+}
+
+type StreamTrade struct {
+	Symbol    string          `json:"symbol"`
+	Timestamp int64           `json:"ts"`
+	Tid       int64           `json:"tid"`
+	Date      int64           `json:"date"`
+	Type      string          `json:"type"`
+	Price     decimal.Decimal `json:"price"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+type StreamOrderBook struct {
+	Symbol    string           `json:"symbol"`
+	Timestamp int64            `json:"timestamp"`
+	Asks      []OrderBookLevel `json:"asks"`
+	Bids      []OrderBookLevel `json:"bids"`
+}
+
+// OrderBookLevel is a price level encoded by the API as a [price, quantity] pair.
+type OrderBookLevel struct {
+	Price    decimal.Decimal
+	Quantity decimal.Decimal
+}
+
+func (l *OrderBookLevel) UnmarshalJSON(bts []byte) error {
+	pair := []decimal.Decimal{}
+	if err := json.Unmarshal(bts, &pair); err != nil {
+		return err
+	}
+	if len(pair) < 2 {
+		return fmt.Errorf("invalid order book level %s", string(bts))
+	}
+	l.Price = pair[0]
+	l.Quantity = pair[1]
+	return nil
+}
+
+func (l OrderBookLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]decimal.Decimal{l.Price, l.Quantity})
+}
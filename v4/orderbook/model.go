@@ -0,0 +1,16 @@
+package orderbook
+
+type Options func(o *BookCfg) error
+
+type BookCfg struct {
+	maxTimeGap int64
+}
+
+// OptMaxTimeGap flags a gap when two consecutive updates are further apart
+// than gap, in the unit of the feed timestamps.
+func OptMaxTimeGap(gap int64) Options {
+	return func(o *BookCfg) error {
+		o.maxTimeGap = gap
+		return nil
+	}
+}
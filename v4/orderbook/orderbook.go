@@ -0,0 +1,295 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// ErrGap is returned when a delta does not follow the last applied update.
+// The book is marked out of sync until a new snapshot is applied.
+var ErrGap = errors.New("order book gap detected")
+
+// ErrNotSynced is returned when a delta arrives before any snapshot.
+var ErrNotSynced = errors.New("order book not synced")
+
+type Side int
+
+const (
+	BID Side = iota
+	ASK
+)
+
+func (s Side) String() string {
+	return [...]string{"bid", "ask"}[s]
+}
+
+// Book is a local copy of the order book of one symbol, with bids sorted from
+// the best (highest) price and asks from the best (lowest) price. It is safe
+// for concurrent use.
+type Book struct {
+	mu        sync.RWMutex
+	cfg       *BookCfg
+	symbol    string
+	bids      []models.OrderBookLevel
+	asks      []models.OrderBookLevel
+	sequence  int64
+	timestamp int64
+	synced    bool
+}
+
+func New(symbol string, opts ...Options) (*Book, error) {
+	mts := &BookCfg{}
+	for _, op := range opts {
+		err := op(mts)
+		if err != nil {
+			return &Book{}, err
+		}
+	}
+
+	return &Book{cfg: mts, symbol: strings.ToUpper(symbol)}, nil
+}
+
+func (b *Book) Symbol() string {
+	return b.symbol
+}
+
+// Synced reports whether the book holds a snapshot without detected gaps.
+func (b *Book) Synced() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.synced
+}
+
+func (b *Book) Sequence() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.sequence
+}
+
+func (b *Book) Timestamp() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.timestamp
+}
+
+// ApplySnapshot replaces the book with the levels of a REST snapshot.
+func (b *Book) ApplySnapshot(snapshot models.OrderBookResponse) {
+	b.reset(snapshot.Asks, snapshot.Bids, 0, int64(snapshot.Timestamp))
+}
+
+// ApplyStream replaces the book with the levels of a streaming snapshot.
+func (b *Book) ApplyStream(snapshot models.StreamOrderBook) {
+	b.reset(snapshot.Asks, snapshot.Bids, 0, snapshot.Timestamp)
+}
+
+// StreamHandler returns a callback for stream.OptOnOrderBook that applies
+// each streaming snapshot to the book of its symbol. Snapshots of other
+// symbols are ignored.
+func StreamHandler(books ...*Book) func(models.StreamOrderBook) {
+	index := make(map[string]*Book, len(books))
+	for _, b := range books {
+		index[b.symbol] = b
+	}
+
+	return func(snapshot models.StreamOrderBook) {
+		if b, ok := index[strings.ToUpper(snapshot.Symbol)]; ok {
+			b.ApplyStream(snapshot)
+		}
+	}
+}
+
+func (b *Book) reset(asks, bids []models.OrderBookLevel, sequence, timestamp int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.asks = b.asks[:0]
+	b.bids = b.bids[:0]
+	for _, l := range asks {
+		b.asks = setLevel(b.asks, l, ASK)
+	}
+	for _, l := range bids {
+		b.bids = setLevel(b.bids, l, BID)
+	}
+	b.sequence = sequence
+	b.timestamp = timestamp
+	b.synced = true
+}
+
+// ApplyDelta applies an incremental update. Deltas already covered by the
+// snapshot are ignored. A missing sequence number, an update older than the
+// book or a time jump above the configured maximum returns ErrGap and leaves
+// the book out of sync.
+func (b *Book) ApplyDelta(delta models.OrderBookDelta) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.synced {
+		return ErrNotSynced
+	}
+
+	if delta.Sequence > 0 && b.sequence > 0 {
+		if delta.Sequence <= b.sequence {
+			return nil
+		}
+		if delta.Sequence != b.sequence+1 {
+			b.synced = false
+			return fmt.Errorf("%w: expected sequence %d, got %d", ErrGap, b.sequence+1, delta.Sequence)
+		}
+	}
+
+	if delta.Timestamp > 0 && b.timestamp > 0 {
+		if delta.Timestamp < b.timestamp {
+			if delta.Sequence > 0 {
+				b.synced = false
+				return fmt.Errorf("%w: timestamp %d older than %d", ErrGap, delta.Timestamp, b.timestamp)
+			}
+			return nil
+		}
+		if b.cfg.maxTimeGap > 0 && delta.Timestamp-b.timestamp > b.cfg.maxTimeGap {
+			b.synced = false
+			return fmt.Errorf("%w: %d since the last update", ErrGap, delta.Timestamp-b.timestamp)
+		}
+	}
+
+	for _, l := range delta.Asks {
+		b.asks = setLevel(b.asks, l, ASK)
+	}
+	for _, l := range delta.Bids {
+		b.bids = setLevel(b.bids, l, BID)
+	}
+
+	if delta.Sequence > 0 {
+		b.sequence = delta.Sequence
+	}
+	if delta.Timestamp > 0 {
+		b.timestamp = delta.Timestamp
+	}
+
+	return nil
+}
+
+// setLevel inserts, updates or removes (zero quantity) a level keeping the
+// side sorted from the best price.
+func setLevel(levels []models.OrderBookLevel, l models.OrderBookLevel, side Side) []models.OrderBookLevel {
+	i := sort.Search(len(levels), func(i int) bool {
+		if side == BID {
+			return levels[i].Price.LessThanOrEqual(l.Price)
+		}
+		return levels[i].Price.GreaterThanOrEqual(l.Price)
+	})
+
+	found := i < len(levels) && levels[i].Price.Equal(l.Price)
+
+	switch {
+	case !l.Quantity.IsPositive() && found:
+		return append(levels[:i], levels[i+1:]...)
+	case !l.Quantity.IsPositive():
+		return levels
+	case found:
+		levels[i].Quantity = l.Quantity
+		return levels
+	}
+
+	levels = append(levels, models.OrderBookLevel{})
+	copy(levels[i+1:], levels[i:])
+	levels[i] = l
+	return levels
+}
+
+func (b *Book) BestBid() (models.OrderBookLevel, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.bids) == 0 {
+		return models.OrderBookLevel{}, false
+	}
+	return b.bids[0], true
+}
+
+func (b *Book) BestAsk() (models.OrderBookLevel, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.asks) == 0 {
+		return models.OrderBookLevel{}, false
+	}
+	return b.asks[0], true
+}
+
+// Spread returns best ask minus best bid.
+func (b *Book) Spread() (decimal.Decimal, bool) {
+	bid, okb := b.BestBid()
+	ask, oka := b.BestAsk()
+	if !okb || !oka {
+		return decimal.Zero, false
+	}
+	return ask.Price.Sub(bid.Price), true
+}
+
+// Mid returns the average between best bid and best ask.
+func (b *Book) Mid() (decimal.Decimal, bool) {
+	bid, okb := b.BestBid()
+	ask, oka := b.BestAsk()
+	if !okb || !oka {
+		return decimal.Zero, false
+	}
+	return ask.Price.Add(bid.Price).Div(decimal.NewFromInt(2)), true
+}
+
+// Levels returns a copy of the first n levels of side, or all when n <= 0.
+func (b *Book) Levels(side Side, n int) []models.OrderBookLevel {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	levels := b.side(side)
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+
+	out := make([]models.OrderBookLevel, n)
+	copy(out, levels[:n])
+	return out
+}
+
+// DepthAt returns the quantity resting at exactly price on side.
+func (b *Book) DepthAt(side Side, price decimal.Decimal) decimal.Decimal {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, l := range b.side(side) {
+		if l.Price.Equal(price) {
+			return l.Quantity
+		}
+	}
+	return decimal.Zero
+}
+
+// CumulativeVolume returns the quantity available on side at price or better:
+// bids at or above price, asks at or below price.
+func (b *Book) CumulativeVolume(side Side, price decimal.Decimal) decimal.Decimal {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	total := decimal.Zero
+	for _, l := range b.side(side) {
+		if side == BID && l.Price.LessThan(price) {
+			break
+		}
+		if side == ASK && l.Price.GreaterThan(price) {
+			break
+		}
+		total = total.Add(l.Quantity)
+	}
+	return total
+}
+
+func (b *Book) side(side Side) []models.OrderBookLevel {
+	if side == BID {
+		return b.bids
+	}
+	return b.asks
+}
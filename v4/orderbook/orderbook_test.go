@@ -0,0 +1,221 @@
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/stream"
+)
+
+func level(price, qty string) models.OrderBookLevel {
+	return models.OrderBookLevel{Price: decimal.RequireFromString(price), Quantity: decimal.RequireFromString(qty)}
+}
+
+func levels(ls ...models.OrderBookLevel) []models.OrderBookLevel {
+	return ls
+}
+
+func assertLevels(t *testing.T, b *Book, side Side, want ...string) {
+	t.Helper()
+	got := b.Levels(side, 0)
+	if len(got) != len(want)/2 {
+		t.Fatalf("%s side has %d levels, want %d: %v", side, len(got), len(want)/2, got)
+	}
+	for i, l := range got {
+		if l.Price.String() != want[2*i] || l.Quantity.String() != want[2*i+1] {
+			t.Errorf("%s level %d is %s@%s, want %s@%s", side, i, l.Quantity, l.Price, want[2*i+1], want[2*i])
+		}
+	}
+}
+
+func newBook(t *testing.T, opts ...Options) *Book {
+	b, err := New("btc-brl", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.ApplySnapshot(models.OrderBookResponse{
+		Asks:      levels(level("101", "1"), level("102", "2")),
+		Bids:      levels(level("99", "1"), level("100", "3")),
+		Timestamp: 1000,
+	})
+	return b
+}
+
+func TestApplyDeltaLevels(t *testing.T) {
+	b := newBook(t)
+	assertLevels(t, b, BID, "100", "3", "99", "1")
+	assertLevels(t, b, ASK, "101", "1", "102", "2")
+
+	err := b.ApplyDelta(models.OrderBookDelta{
+		Sequence:  1,
+		Timestamp: 1001,
+		Asks:      levels(level("101", "0"), level("103", "4")),
+		Bids:      levels(level("100", "5"), level("98", "0")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 101 is removed, 98 was not in the book and is ignored.
+	assertLevels(t, b, ASK, "102", "2", "103", "4")
+	assertLevels(t, b, BID, "100", "5", "99", "1")
+
+	spread, _ := b.Spread()
+	if spread.String() != "2" {
+		t.Errorf("spread %s, want 2", spread)
+	}
+}
+
+func TestApplyDeltaGap(t *testing.T) {
+	b := newBook(t)
+
+	if err := b.ApplyDelta(models.OrderBookDelta{Sequence: 5, Timestamp: 1001, Bids: levels(level("100", "4"))}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Already applied.
+	if err := b.ApplyDelta(models.OrderBookDelta{Sequence: 5, Timestamp: 1001, Bids: levels(level("100", "9"))}); err != nil {
+		t.Fatal(err)
+	}
+	assertLevels(t, b, BID, "100", "4", "99", "1")
+
+	err := b.ApplyDelta(models.OrderBookDelta{Sequence: 7, Timestamp: 1002, Bids: levels(level("100", "1"))})
+	if !errors.Is(err, ErrGap) {
+		t.Fatalf("expected ErrGap, got %v", err)
+	}
+	if b.Synced() {
+		t.Error("book still synced after a gap")
+	}
+	assertLevels(t, b, BID, "100", "4", "99", "1")
+
+	if err := b.ApplyDelta(models.OrderBookDelta{Sequence: 8}); !errors.Is(err, ErrNotSynced) {
+		t.Errorf("expected ErrNotSynced, got %v", err)
+	}
+}
+
+func TestApplyDeltaTimeGap(t *testing.T) {
+	b := newBook(t, OptMaxTimeGap(10))
+
+	if err := b.ApplyDelta(models.OrderBookDelta{Timestamp: 1005}); err != nil {
+		t.Fatal(err)
+	}
+	// Older updates without a sequence are ignored.
+	if err := b.ApplyDelta(models.OrderBookDelta{Timestamp: 1001, Asks: levels(level("101", "0"))}); err != nil {
+		t.Fatal(err)
+	}
+	assertLevels(t, b, ASK, "101", "1", "102", "2")
+
+	if err := b.ApplyDelta(models.OrderBookDelta{Timestamp: 1100}); !errors.Is(err, ErrGap) {
+		t.Fatalf("expected ErrGap, got %v", err)
+	}
+}
+
+func TestSyncerResnapshot(t *testing.T) {
+	b, err := New("BTC-BRL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots := 0
+	syncer := NewSyncer(b, func(ctx context.Context) (models.OrderBookResponse, error) {
+		snapshots++
+		return models.OrderBookResponse{
+			Asks:      levels(level("101", "1")),
+			Bids:      levels(level("100", "1")),
+			Timestamp: 1000 * snapshots,
+		}, nil
+	})
+
+	ctx := context.Background()
+
+	// The first delta seeds the book.
+	if err := syncer.Apply(ctx, models.OrderBookDelta{Sequence: 1, Timestamp: 1001, Bids: levels(level("100", "2"))}); err != nil {
+		t.Fatal(err)
+	}
+	if snapshots != 1 {
+		t.Fatalf("%d snapshots, want 1", snapshots)
+	}
+	assertLevels(t, b, BID, "100", "2")
+
+	if err := syncer.Apply(ctx, models.OrderBookDelta{Sequence: 2, Timestamp: 1002, Bids: levels(level("100", "0"))}); err != nil {
+		t.Fatal(err)
+	}
+	assertLevels(t, b, BID)
+
+	// A gap takes a new snapshot and drops the delta.
+	if err := syncer.Apply(ctx, models.OrderBookDelta{Sequence: 4, Timestamp: 1004, Bids: levels(level("99", "7"))}); err != nil {
+		t.Fatal(err)
+	}
+	if snapshots != 2 {
+		t.Fatalf("%d snapshots, want 2", snapshots)
+	}
+	if !b.Synced() || b.Timestamp() != 2000 {
+		t.Errorf("book not replaced by the snapshot: synced %v, timestamp %d", b.Synced(), b.Timestamp())
+	}
+	assertLevels(t, b, BID, "100", "1")
+
+	failing := NewSyncer(b, func(ctx context.Context) (models.OrderBookResponse, error) {
+		return models.OrderBookResponse{}, errors.New("unavailable")
+	})
+	if err := failing.Apply(ctx, models.OrderBookDelta{Sequence: 9, Timestamp: 3000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := failing.Apply(ctx, models.OrderBookDelta{Sequence: 11, Timestamp: 3001}); err == nil {
+		t.Error("expected the snapshot error")
+	}
+}
+
+func TestStreamHandler(t *testing.T) {
+	feed := mockserver.NewStream()
+	defer feed.Close()
+
+	btc, _ := New("BTC-BRL")
+	eth, _ := New("ETH-BRL")
+
+	s, err := stream.New(stream.OptEndpoint(feed.URL()), stream.OptOnOrderBook(StreamHandler(btc, eth)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SubscribeOrderBook("BTC-BRL", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(feed.Subscriptions()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no subscription")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	book := map[string]interface{}{"timestamp": 42, "asks": [][]string{{"101", "1"}}, "bids": [][]string{{"100", "2"}, {"99", "1"}}}
+	if err := feed.Send("orderbook", "BRLBTC", book); err != nil {
+		t.Fatal(err)
+	}
+
+	for btc.Timestamp() != 42 {
+		if time.Now().After(deadline) {
+			t.Fatal("book not updated")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	assertLevels(t, btc, BID, "100", "2", "99", "1")
+	assertLevels(t, btc, ASK, "101", "1")
+	if eth.Synced() {
+		t.Error("snapshot applied to another symbol")
+	}
+}
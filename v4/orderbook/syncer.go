@@ -0,0 +1,74 @@
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/thiagozs/go-mbsdk/v4/api"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// SnapshotFunc fetches a full order book, usually from the REST API.
+type SnapshotFunc func(ctx context.Context) (models.OrderBookResponse, error)
+
+// APISnapshot returns a SnapshotFunc backed by Api.OrderBook.
+func APISnapshot(a *api.Api, symbol, limit string) SnapshotFunc {
+	return func(ctx context.Context) (models.OrderBookResponse, error) {
+		return a.OrderBookWithContext(ctx, symbol, limit)
+	}
+}
+
+// Syncer keeps a Book up to date from deltas, taking a new snapshot whenever
+// the book is out of sync. The deltas are supplied by the caller: the feed of
+// the stream package only sends full snapshots, which go to the book through
+// StreamHandler instead.
+type Syncer struct {
+	mu       sync.Mutex
+	book     *Book
+	snapshot SnapshotFunc
+}
+
+func NewSyncer(book *Book, snapshot SnapshotFunc) *Syncer {
+	return &Syncer{book: book, snapshot: snapshot}
+}
+
+func (s *Syncer) Book() *Book {
+	return s.book
+}
+
+// Sync replaces the book with a fresh snapshot.
+func (s *Syncer) Sync(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sync(ctx)
+}
+
+func (s *Syncer) sync(ctx context.Context) error {
+	snapshot, err := s.snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	s.book.ApplySnapshot(snapshot)
+	return nil
+}
+
+// Apply applies delta, taking a snapshot first when the book is not synced and
+// again when a gap is detected. The delta that revealed the gap is dropped
+// since the new snapshot already covers it.
+func (s *Syncer) Apply(ctx context.Context, delta models.OrderBookDelta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.book.Synced() {
+		if err := s.sync(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := s.book.ApplyDelta(delta)
+	if errors.Is(err, ErrGap) {
+		return s.sync(ctx)
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+package paper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// MarketData supplies the order books used to fill the simulated orders.
+type MarketData interface {
+	OrderBook(ctx context.Context, symbol string) (models.OrderBookResponse, error)
+}
+
+// MarketDataFunc adapts a function, e.g. one calling Api.OrderBook, to
+// MarketData.
+type MarketDataFunc func(ctx context.Context, symbol string) (models.OrderBookResponse, error)
+
+func (f MarketDataFunc) OrderBook(ctx context.Context, symbol string) (models.OrderBookResponse, error) {
+	return f(ctx, symbol)
+}
+
+// Recorded serves order books saved beforehand, to replay a session or run
+// without network. It is safe for concurrent use.
+type Recorded struct {
+	mu    sync.RWMutex
+	books map[string]models.OrderBookResponse
+}
+
+func NewRecorded() *Recorded {
+	return &Recorded{books: make(map[string]models.OrderBookResponse)}
+}
+
+// SetOrderBook replaces the book of symbol seen by the next fills.
+func (r *Recorded) SetOrderBook(symbol string, book models.OrderBookResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.books[strings.ToUpper(symbol)] = book
+}
+
+func (r *Recorded) OrderBook(ctx context.Context, symbol string) (models.OrderBookResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	book, ok := r.books[strings.ToUpper(symbol)]
+	if !ok {
+		return book, &Error{Status: http.StatusNotFound, Code: "PAPER|SYMBOL_NOT_FOUND", Message: fmt.Sprintf("no order book recorded for %s", symbol)}
+	}
+	return book, nil
+}
+
+// Error mirrors the error answers of the exchange, so the api package reports
+// simulated rejections the same way as real ones.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s - %s", e.Code, e.Message)
+}
+
+func reject(code, format string, args ...interface{}) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+type Options func(o *AccountCfg) error
+
+type AccountCfg struct {
+	balances map[string]decimal.Decimal
+	makerFee decimal.Decimal
+	takerFee decimal.Decimal
+	slippage decimal.Decimal
+	market   MarketData
+}
+
+// OptBalance sets the starting balance of asset.
+func OptBalance(asset, amount string) Options {
+	return func(o *AccountCfg) error {
+		value, err := decimal.NewFromString(amount)
+		if err != nil {
+			return fmt.Errorf("invalid balance %q for %s: %w", amount, asset, err)
+		}
+		if value.IsNegative() {
+			return fmt.Errorf("invalid balance %q for %s", amount, asset)
+		}
+		o.balances[strings.ToUpper(asset)] = value
+		return nil
+	}
+}
+
+// OptFees sets the maker and taker fee rates, e.g. "0.003" for 0.3%. The fee
+// is charged on the asset received.
+func OptFees(maker, taker string) Options {
+	return func(o *AccountCfg) error {
+		m, err := decimal.NewFromString(maker)
+		if err != nil {
+			return fmt.Errorf("invalid maker fee %q: %w", maker, err)
+		}
+		t, err := decimal.NewFromString(taker)
+		if err != nil {
+			return fmt.Errorf("invalid taker fee %q: %w", taker, err)
+		}
+		o.makerFee = m
+		o.takerFee = t
+		return nil
+	}
+}
+
+// OptSlippage worsens the price of every taker fill by rate, e.g. "0.0005"
+// for 5 basis points. Limit orders never fill beyond their limit price.
+func OptSlippage(rate string) Options {
+	return func(o *AccountCfg) error {
+		value, err := decimal.NewFromString(rate)
+		if err != nil {
+			return fmt.Errorf("invalid slippage %q: %w", rate, err)
+		}
+		if value.IsNegative() || value.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+			return fmt.Errorf("invalid slippage %q", rate)
+		}
+		o.slippage = value
+		return nil
+	}
+}
+
+func OptMarketData(market MarketData) Options {
+	return func(o *AccountCfg) error {
+		o.market = market
+		return nil
+	}
+}
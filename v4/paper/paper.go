@@ -0,0 +1,538 @@
+package paper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/utils"
+)
+
+// qtyPlaces is the precision used when a market buy by cost is converted to
+// quantity.
+const qtyPlaces = 8
+
+type balance struct {
+	available decimal.Decimal
+	onHold    decimal.Decimal
+}
+
+type order struct {
+	models.GetOrderResponse
+	seq  int64
+	cost decimal.Decimal
+	// held is what is still reserved for the order: quote for buys, base for
+	// sells.
+	held decimal.Decimal
+}
+
+// Account is a simulated trading account. Orders take liquidity from the
+// order books given by MarketData, paying the taker fee and the slippage.
+// Limit orders left on the book fill at their limit price, paying the maker
+// fee, once the opposite best price reaches it, each check taking at most the
+// quantity of that best level. Market orders the book cannot fill entirely
+// are cancelled with what was filled. Stop orders are activated when the
+// opposite best price reaches the stop price. Resting orders are checked
+// whenever the account is read. It is safe for concurrent use.
+type Account struct {
+	cfg *AccountCfg
+
+	mu       sync.Mutex
+	balances map[string]*balance
+	orders   map[string]*order
+	seq      int64
+}
+
+func New(opts ...Options) (*Account, error) {
+	mts := &AccountCfg{balances: make(map[string]decimal.Decimal)}
+	for _, op := range opts {
+		err := op(mts)
+		if err != nil {
+			return &Account{}, err
+		}
+	}
+
+	if mts.market == nil {
+		return &Account{}, fmt.Errorf("market data is required")
+	}
+
+	a := &Account{
+		cfg:      mts,
+		balances: make(map[string]*balance),
+		orders:   make(map[string]*order),
+	}
+	for asset, amount := range mts.balances {
+		a.wallet(asset).available = amount
+	}
+
+	return a, nil
+}
+
+// Place simulates the placement of p on symbol and returns the order as the
+// exchange would report it right after.
+func (a *Account) Place(ctx context.Context, symbol string, p models.PlaceOrderPayload) (models.GetOrderResponse, error) {
+	symbol = strings.ToUpper(symbol)
+	if !strings.Contains(symbol, "-") {
+		return models.GetOrderResponse{}, reject("TRADING|INVALID_SYMBOL", "invalid symbol %s", symbol)
+	}
+
+	if err := check(p); err != nil {
+		return models.GetOrderResponse{}, err
+	}
+
+	book, err := a.cfg.market.OrderBook(ctx, symbol)
+	if err != nil {
+		return models.GetOrderResponse{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.seq++
+	now := int(time.Now().Unix())
+	o := &order{
+		GetOrderResponse: models.GetOrderResponse{
+			CreatedAt:  now,
+			ExternalID: p.ExternalID,
+			ID:         fmt.Sprint(a.seq),
+			Instrument: symbol,
+			LimitPrice: p.LimitPrice,
+			Qty:        p.Qty,
+			Side:       p.Side,
+			StopPrice:  p.StopPrice,
+			Type:       p.Type,
+			UpdatedAt:  now,
+		},
+		seq:  a.seq,
+		cost: p.Cost,
+	}
+	if o.Type == "market" {
+		o.LimitPrice = decimal.Zero
+	}
+
+	if err := a.reserve(o, book); err != nil {
+		return models.GetOrderResponse{}, err
+	}
+
+	a.orders[o.ID] = o
+
+	if o.Type == "stoplimit" {
+		o.Status = "created"
+		a.trigger(o, book)
+	} else {
+		o.Status = "working"
+		a.take(o, book)
+		a.settle(o)
+	}
+
+	return o.snapshot(), nil
+}
+
+func check(p models.PlaceOrderPayload) error {
+	switch {
+	case p.Side != "buy" && p.Side != "sell":
+		return reject("TRADING|INVALID_SIDE", "invalid side %q", p.Side)
+	case p.Type != "limit" && p.Type != "market" && p.Type != "stoplimit":
+		return reject("TRADING|INVALID_TYPE", "invalid type %q", p.Type)
+	case p.Type != "market" && !p.LimitPrice.IsPositive():
+		return reject("TRADING|INVALID_PRICE", "limitPrice is required")
+	case p.Type == "stoplimit" && !p.StopPrice.IsPositive():
+		return reject("TRADING|INVALID_PRICE", "stopPrice is required")
+	case p.Qty.IsNegative() || p.Cost.IsNegative():
+		return reject("TRADING|INVALID_QUANTITY", "qty and cost must be positive")
+	case p.Qty.IsZero() && !(p.Type == "market" && p.Side == "buy" && p.Cost.IsPositive()):
+		return reject("TRADING|INVALID_QUANTITY", "qty is required")
+	}
+	return nil
+}
+
+func (a *Account) reserve(o *order, book models.OrderBookResponse) error {
+	base, quote := utils.PairQuote(o.Instrument)
+
+	asset, amount := quote, o.Qty.Mul(o.LimitPrice)
+	switch {
+	case o.Side == "sell":
+		asset, amount = base, o.Qty
+	case o.Type == "market" && o.Qty.IsZero():
+		amount = o.cost
+	case o.Type == "market":
+		amount = a.estimate(o, book)
+	}
+
+	b := a.wallet(asset)
+	if b.available.LessThan(amount) {
+		return reject("TRADING|INSUFFICIENT_BALANCE", "insufficient %s balance: %s available, %s required",
+			asset, b.available, amount)
+	}
+	b.available = b.available.Sub(amount)
+	b.onHold = b.onHold.Add(amount)
+	o.held = amount
+	return nil
+}
+
+// estimate is the quote needed to fill a market buy by quantity against
+// book, slippage included.
+func (a *Account) estimate(o *order, book models.OrderBookResponse) decimal.Decimal {
+	remaining := o.Qty
+	total := decimal.Zero
+	for _, level := range book.Asks {
+		if !remaining.IsPositive() {
+			break
+		}
+		qty := decimal.Min(remaining, level.Quantity)
+		total = total.Add(qty.Mul(a.slipped(o, level.Price)))
+		remaining = remaining.Sub(qty)
+	}
+	return total
+}
+
+// slipped returns the price paid by a taker order after slippage, never
+// beyond the limit price.
+func (a *Account) slipped(o *order, price decimal.Decimal) decimal.Decimal {
+	one := decimal.NewFromInt(1)
+	if o.Side == "buy" {
+		price = price.Mul(one.Add(a.cfg.slippage))
+		if o.Type != "market" && price.GreaterThan(o.LimitPrice) {
+			price = o.LimitPrice
+		}
+		return price
+	}
+
+	price = price.Mul(one.Sub(a.cfg.slippage))
+	if o.Type != "market" && price.LessThan(o.LimitPrice) {
+		price = o.LimitPrice
+	}
+	return price
+}
+
+func (o *order) remaining() decimal.Decimal {
+	return o.Qty.Sub(o.FilledQty)
+}
+
+func (o *order) open() bool {
+	if o.Type == "market" && o.Qty.IsZero() {
+		return o.held.IsPositive()
+	}
+	return o.remaining().IsPositive()
+}
+
+// take fills o against the opposite side of book as a taker.
+func (a *Account) take(o *order, book models.OrderBookResponse) {
+	levels := book.Asks
+	if o.Side == "sell" {
+		levels = book.Bids
+	}
+
+	for _, level := range levels {
+		if !o.open() {
+			return
+		}
+
+		if o.Type != "market" {
+			if o.Side == "buy" && level.Price.GreaterThan(o.LimitPrice) {
+				return
+			}
+			if o.Side == "sell" && level.Price.LessThan(o.LimitPrice) {
+				return
+			}
+		}
+
+		price := a.slipped(o, level.Price)
+		qty := level.Quantity
+		if o.Type == "market" && o.Qty.IsZero() {
+			qty = decimal.Min(qty, o.held.Div(price).RoundDown(qtyPlaces))
+		} else {
+			qty = decimal.Min(qty, o.remaining())
+		}
+		if !qty.IsPositive() {
+			return
+		}
+
+		a.fill(o, price, qty, a.cfg.takerFee)
+	}
+}
+
+// settle leaves a limit order with quantity left on the book and closes any
+// other order, releasing what it no longer needs. An order closed before
+// getting all it asked for is cancelled, keeping its partial fill.
+func (a *Account) settle(o *order) {
+	if o.Type != "market" && o.open() {
+		return
+	}
+
+	if o.complete() {
+		o.Status = "filled"
+	} else {
+		o.Status = "cancelled"
+	}
+	a.release(o)
+}
+
+// complete reports whether o got all it asked for. A market buy by cost is
+// complete once what is left cannot buy a quantity step at the last price.
+func (o *order) complete() bool {
+	if o.Type == "market" && o.Qty.IsZero() {
+		n := len(o.Executions)
+		if n == 0 {
+			return false
+		}
+		return o.held.LessThan(o.Executions[n-1].Price.Mul(decimal.New(1, -qtyPlaces)))
+	}
+	return !o.remaining().IsPositive()
+}
+
+func (a *Account) fill(o *order, price, qty, feeRate decimal.Decimal) {
+	base, quote := utils.PairQuote(o.Instrument)
+	notional := price.Mul(qty)
+
+	o.AvgPrice = o.AvgPrice.Mul(o.FilledQty).Add(notional).Div(o.FilledQty.Add(qty))
+	o.FilledQty = o.FilledQty.Add(qty)
+	o.UpdatedAt = int(time.Now().Unix())
+
+	a.seq++
+	o.Executions = append(o.Executions, models.OrderExecution{
+		ExecutedAt: o.UpdatedAt,
+		FeeRate:    feeRate,
+		ID:         fmt.Sprint(a.seq),
+		Instrument: o.Instrument,
+		Price:      price,
+		Qty:        qty,
+		Side:       o.Side,
+	})
+
+	if o.Side == "buy" {
+		fee := qty.Mul(feeRate)
+		o.held = o.held.Sub(notional)
+		a.wallet(quote).onHold = a.wallet(quote).onHold.Sub(notional)
+		a.wallet(base).available = a.wallet(base).available.Add(qty.Sub(fee))
+		o.Fee = o.Fee.Add(fee)
+		return
+	}
+
+	fee := notional.Mul(feeRate)
+	o.held = o.held.Sub(qty)
+	a.wallet(base).onHold = a.wallet(base).onHold.Sub(qty)
+	a.wallet(quote).available = a.wallet(quote).available.Add(notional.Sub(fee))
+	o.Fee = o.Fee.Add(fee)
+}
+
+func (a *Account) release(o *order) {
+	if !o.held.IsPositive() {
+		o.held = decimal.Zero
+		return
+	}
+
+	base, quote := utils.PairQuote(o.Instrument)
+	asset := quote
+	if o.Side == "sell" {
+		asset = base
+	}
+
+	b := a.wallet(asset)
+	b.onHold = b.onHold.Sub(o.held)
+	b.available = b.available.Add(o.held)
+	o.held = decimal.Zero
+}
+
+// trigger activates a stop order once the opposite best price reaches the
+// stop price.
+func (a *Account) trigger(o *order, book models.OrderBookResponse) {
+	switch {
+	case o.Side == "buy" && len(book.Asks) > 0 && book.Asks[0].Price.GreaterThanOrEqual(o.StopPrice):
+	case o.Side == "sell" && len(book.Bids) > 0 && book.Bids[0].Price.LessThanOrEqual(o.StopPrice):
+	default:
+		return
+	}
+
+	o.Status = "working"
+	o.UpdatedAt = int(time.Now().Unix())
+	a.take(o, book)
+	a.settle(o)
+}
+
+// rest fills a resting limit order when the opposite best price reaches it,
+// up to the quantity of that level.
+func (a *Account) rest(o *order, book models.OrderBookResponse) {
+	var best models.OrderBookLevel
+	switch {
+	case o.Side == "buy" && len(book.Asks) > 0 && book.Asks[0].Price.LessThanOrEqual(o.LimitPrice):
+		best = book.Asks[0]
+	case o.Side == "sell" && len(book.Bids) > 0 && book.Bids[0].Price.GreaterThanOrEqual(o.LimitPrice):
+		best = book.Bids[0]
+	default:
+		return
+	}
+
+	qty := decimal.Min(o.remaining(), best.Quantity)
+	if !qty.IsPositive() {
+		return
+	}
+
+	a.fill(o, o.LimitPrice, qty, a.cfg.makerFee)
+	a.settle(o)
+}
+
+// Sync checks the open orders against the current order books.
+func (a *Account) Sync(ctx context.Context) error {
+	a.mu.Lock()
+	symbols := map[string]bool{}
+	for _, o := range a.orders {
+		if o.Status == "working" || o.Status == "created" {
+			symbols[o.Instrument] = true
+		}
+	}
+	a.mu.Unlock()
+
+	for symbol := range symbols {
+		book, err := a.cfg.market.OrderBook(ctx, symbol)
+		if err != nil {
+			return err
+		}
+
+		a.mu.Lock()
+		for _, o := range a.sorted(symbol) {
+			switch o.Status {
+			case "created":
+				a.trigger(o, book)
+			case "working":
+				a.rest(o, book)
+			}
+		}
+		a.mu.Unlock()
+	}
+
+	return nil
+}
+
+func (a *Account) Cancel(ctx context.Context, symbol, id string) error {
+	if err := a.Sync(ctx); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	o, ok := a.orders[id]
+	if !ok || o.Instrument != strings.ToUpper(symbol) {
+		return &Error{Status: http.StatusNotFound, Code: "TRADING|ORDER_NOT_FOUND", Message: fmt.Sprintf("order %s not found", id)}
+	}
+
+	if !a.cancel(o) {
+		return reject("TRADING|ORDER_ALREADY_CLOSED", "order %s is %s", o.ID, o.Status)
+	}
+	return nil
+}
+
+// CancelAll cancels the open orders of symbol, or of every symbol when empty,
+// and returns their ids.
+func (a *Account) CancelAll(ctx context.Context, symbol string) ([]string, error) {
+	if err := a.Sync(ctx); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ids := []string{}
+	for _, o := range a.sorted(strings.ToUpper(symbol)) {
+		if a.cancel(o) {
+			ids = append(ids, o.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (a *Account) cancel(o *order) bool {
+	if o.Status != "working" && o.Status != "created" {
+		return false
+	}
+	a.release(o)
+	o.Status = "cancelled"
+	o.UpdatedAt = int(time.Now().Unix())
+	return true
+}
+
+func (a *Account) Order(ctx context.Context, symbol, id string) (models.GetOrderResponse, error) {
+	if err := a.Sync(ctx); err != nil {
+		return models.GetOrderResponse{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	o, ok := a.orders[id]
+	if !ok || o.Instrument != strings.ToUpper(symbol) {
+		return models.GetOrderResponse{}, &Error{Status: http.StatusNotFound, Code: "TRADING|ORDER_NOT_FOUND", Message: fmt.Sprintf("order %s not found", id)}
+	}
+	return o.snapshot(), nil
+}
+
+// Orders returns the orders of symbol, oldest first.
+func (a *Account) Orders(ctx context.Context, symbol string) (models.ListOrderResponse, error) {
+	if err := a.Sync(ctx); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	orders := models.ListOrderResponse{}
+	for _, o := range a.sorted(strings.ToUpper(symbol)) {
+		orders = append(orders, o.snapshot())
+	}
+	return orders, nil
+}
+
+func (a *Account) Balances(ctx context.Context) (models.ListBalancesResponse, error) {
+	if err := a.Sync(ctx); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	balances := models.ListBalancesResponse{}
+	for asset, b := range a.balances {
+		balances = append(balances, models.Balance{
+			Available: b.available,
+			OnHold:    b.onHold,
+			Symbol:    asset,
+			Total:     b.available.Add(b.onHold),
+		})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Symbol < balances[j].Symbol })
+	return balances, nil
+}
+
+// sorted returns the orders of symbol, or all when empty, oldest first.
+func (a *Account) sorted(symbol string) []*order {
+	orders := []*order{}
+	for _, o := range a.orders {
+		if len(symbol) == 0 || o.Instrument == symbol {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].seq < orders[j].seq })
+	return orders
+}
+
+func (a *Account) wallet(asset string) *balance {
+	b, ok := a.balances[asset]
+	if !ok {
+		b = &balance{}
+		a.balances[asset] = b
+	}
+	return b
+}
+
+func (o *order) snapshot() models.GetOrderResponse {
+	resp := o.GetOrderResponse
+	resp.Executions = append([]models.OrderExecution{}, o.Executions...)
+	return resp
+}
@@ -0,0 +1,217 @@
+package paper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+func book(asks, bids [][2]string) models.OrderBookResponse {
+	side := func(ls [][2]string) []models.OrderBookLevel {
+		levels := []models.OrderBookLevel{}
+		for _, l := range ls {
+			levels = append(levels, models.OrderBookLevel{
+				Price:    decimal.RequireFromString(l[0]),
+				Quantity: decimal.RequireFromString(l[1]),
+			})
+		}
+		return levels
+	}
+	return models.OrderBookResponse{Asks: side(asks), Bids: side(bids)}
+}
+
+func newAccount(t *testing.T, b models.OrderBookResponse, opts ...Options) (*Account, *Recorded) {
+	t.Helper()
+
+	books := NewRecorded()
+	books.SetOrderBook("BTC-BRL", b)
+	a, err := New(append([]Options{OptMarketData(books)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, books
+}
+
+func payload(side, kind, qty, price string) models.PlaceOrderPayload {
+	p := models.PlaceOrderPayload{Side: side, Type: kind, Qty: decimal.RequireFromString(qty)}
+	if len(price) > 0 {
+		p.LimitPrice = decimal.RequireFromString(price)
+	}
+	return p
+}
+
+func place(t *testing.T, a *Account, p models.PlaceOrderPayload) models.GetOrderResponse {
+	t.Helper()
+
+	o, err := a.Place(context.Background(), "BTC-BRL", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func balanceOf(t *testing.T, a *Account, asset string) models.Balance {
+	t.Helper()
+
+	balances, err := a.Balances(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range balances {
+		if b.Symbol == asset {
+			return b
+		}
+	}
+	return models.Balance{Symbol: asset}
+}
+
+func assertDecimal(t *testing.T, what string, got decimal.Decimal, want string) {
+	t.Helper()
+	if !got.Equal(decimal.RequireFromString(want)) {
+		t.Errorf("%s is %s, want %s", what, got, want)
+	}
+}
+
+func TestPartialMarketFill(t *testing.T) {
+	a, _ := newAccount(t, book([][2]string{{"100", "1"}}, nil), OptBalance("BRL", "1000"))
+
+	o := place(t, a, payload("buy", "market", "5", ""))
+	if o.Status != "cancelled" {
+		t.Errorf("partially filled market order is %s, want cancelled", o.Status)
+	}
+	assertDecimal(t, "filled quantity", o.FilledQty, "1")
+
+	brl := balanceOf(t, a, "BRL")
+	assertDecimal(t, "BRL available", brl.Available, "900")
+	assertDecimal(t, "BRL on hold", brl.OnHold, "0")
+	assertDecimal(t, "BTC available", balanceOf(t, a, "BTC").Available, "1")
+
+	// Spending all the cost completes a market buy by cost.
+	p := payload("buy", "market", "0", "")
+	p.Cost = decimal.RequireFromString("50")
+	a, _ = newAccount(t, book([][2]string{{"100", "5"}}, nil), OptBalance("BRL", "1000"))
+	if o := place(t, a, p); o.Status != "filled" {
+		t.Errorf("market buy by cost is %s, want filled", o.Status)
+	}
+}
+
+func TestRestingFillCappedByDepth(t *testing.T) {
+	a, books := newAccount(t, book([][2]string{{"110", "5"}}, nil), OptBalance("BRL", "1000"))
+
+	o := place(t, a, payload("buy", "limit", "3", "100"))
+	if o.Status != "working" {
+		t.Fatalf("order is %s, want working", o.Status)
+	}
+
+	books.SetOrderBook("BTC-BRL", book([][2]string{{"100", "1"}}, nil))
+	o, err := a.Order(context.Background(), "BTC-BRL", o.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != "working" {
+		t.Errorf("order is %s after a partial fill, want working", o.Status)
+	}
+	assertDecimal(t, "filled quantity", o.FilledQty, "1")
+
+	// Every read checks the book again, so move it away first.
+	books.SetOrderBook("BTC-BRL", book([][2]string{{"110", "5"}}, nil))
+	assertDecimal(t, "BRL on hold", balanceOf(t, a, "BRL").OnHold, "200")
+
+	books.SetOrderBook("BTC-BRL", book([][2]string{{"99", "10"}}, nil))
+	o, err = a.Order(context.Background(), "BTC-BRL", o.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != "filled" || len(o.Executions) != 2 {
+		t.Fatalf("order %s with %d executions, want filled with 2", o.Status, len(o.Executions))
+	}
+	// Resting orders fill at their limit price.
+	assertDecimal(t, "average price", o.AvgPrice, "100")
+	assertDecimal(t, "BRL available", balanceOf(t, a, "BRL").Available, "700")
+	assertDecimal(t, "BTC available", balanceOf(t, a, "BTC").Available, "3")
+}
+
+func TestFees(t *testing.T) {
+	a, books := newAccount(t, book([][2]string{{"100", "10"}}, [][2]string{{"90", "10"}}),
+		OptBalance("BRL", "1000"), OptBalance("BTC", "1"), OptFees("0.001", "0.002"))
+
+	// Buys pay the fee in the base asset.
+	o := place(t, a, payload("buy", "market", "1", ""))
+	assertDecimal(t, "buy fee", o.Fee, "0.002")
+	assertDecimal(t, "BTC available", balanceOf(t, a, "BTC").Available, "1.998")
+
+	// Sells pay it in the quote asset.
+	o = place(t, a, payload("sell", "market", "1", ""))
+	assertDecimal(t, "sell fee", o.Fee, "0.18")
+	assertDecimal(t, "BRL available", balanceOf(t, a, "BRL").Available, "989.82")
+
+	// Resting orders pay the maker fee.
+	o = place(t, a, payload("sell", "limit", "0.5", "120"))
+	books.SetOrderBook("BTC-BRL", book(nil, [][2]string{{"125", "1"}}))
+	o, err := a.Order(context.Background(), "BTC-BRL", o.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != "filled" {
+		t.Fatalf("order is %s, want filled", o.Status)
+	}
+	assertDecimal(t, "maker fee", o.Fee, "0.06")
+	assertDecimal(t, "maker fee rate", o.Executions[0].FeeRate, "0.001")
+	assertDecimal(t, "BRL available", balanceOf(t, a, "BRL").Available, "1049.76")
+}
+
+func TestSlippage(t *testing.T) {
+	a, _ := newAccount(t, book([][2]string{{"100", "10"}}, [][2]string{{"90", "10"}}),
+		OptBalance("BRL", "1000"), OptBalance("BTC", "1"), OptSlippage("0.01"))
+
+	o := place(t, a, payload("buy", "market", "1", ""))
+	assertDecimal(t, "market buy price", o.AvgPrice, "101")
+	assertDecimal(t, "BRL available", balanceOf(t, a, "BRL").Available, "899")
+
+	o = place(t, a, payload("sell", "market", "1", ""))
+	assertDecimal(t, "market sell price", o.AvgPrice, "89.1")
+
+	// Limit orders never fill beyond their limit price.
+	o = place(t, a, payload("buy", "limit", "1", "100.5"))
+	assertDecimal(t, "limit buy price", o.AvgPrice, "100.5")
+}
+
+func TestReserveAndRelease(t *testing.T) {
+	a, _ := newAccount(t, book([][2]string{{"110", "5"}}, [][2]string{{"90", "5"}}),
+		OptBalance("BRL", "1000"), OptBalance("BTC", "1"))
+
+	buy := place(t, a, payload("buy", "limit", "2", "100"))
+	sell := place(t, a, payload("sell", "limit", "1", "120"))
+
+	brl, btc := balanceOf(t, a, "BRL"), balanceOf(t, a, "BTC")
+	assertDecimal(t, "BRL on hold", brl.OnHold, "200")
+	assertDecimal(t, "BRL available", brl.Available, "800")
+	assertDecimal(t, "BTC on hold", btc.OnHold, "1")
+	assertDecimal(t, "BTC available", btc.Available, "0")
+
+	_, err := a.Place(context.Background(), "BTC-BRL", payload("buy", "limit", "9", "100"))
+	var perr *Error
+	if !errors.As(err, &perr) || perr.Code != "TRADING|INSUFFICIENT_BALANCE" {
+		t.Fatalf("expected an insufficient balance, got %v", err)
+	}
+
+	ids, err := a.CancelAll(context.Background(), "BTC-BRL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != buy.ID || ids[1] != sell.ID {
+		t.Errorf("cancelled %v, want [%s %s]", ids, buy.ID, sell.ID)
+	}
+
+	brl, btc = balanceOf(t, a, "BRL"), balanceOf(t, a, "BTC")
+	assertDecimal(t, "BRL on hold after cancel", brl.OnHold, "0")
+	assertDecimal(t, "BRL available after cancel", brl.Available, "1000")
+	assertDecimal(t, "BTC available after cancel", btc.Available, "1")
+
+	if err := a.Cancel(context.Background(), "BTC-BRL", buy.ID); !errors.As(err, &perr) || perr.Code != "TRADING|ORDER_ALREADY_CLOSED" {
+		t.Errorf("expected the order to be closed, got %v", err)
+	}
+}
@@ -2,6 +2,9 @@ package caller
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
 
 	"github.com/thiagozs/go-mbsdk/v4/config"
 	"github.com/thiagozs/go-mbsdk/v4/models"
@@ -9,16 +12,116 @@ import (
 	"github.com/thiagozs/go-mbsdk/v4/pkg/client"
 )
 
-func ClientWithToken(method string, g *cache.Cache) (client.HttpClientPort, error) {
-	c := client.NewHttpClient(3, 3, 3)
+type Options func(o *CallerCfg) error
+
+type CallerCfg struct {
+	httpClient *http.Client
+	policy     client.RetryPolicy
+}
+
+// OptHTTPClient sends the requests through hc, keeping its transport,
+// timeouts and cookie jar.
+func OptHTTPClient(hc *http.Client) Options {
+	return func(o *CallerCfg) error {
+		o.httpClient = hc
+		return nil
+	}
+}
+
+func OptRetryPolicy(policy client.RetryPolicy) Options {
+	return func(o *CallerCfg) error {
+		if policy.RetryMax < 0 || policy.RetryWaitMin < 0 || policy.RetryWaitMax < policy.RetryWaitMin {
+			return fmt.Errorf("invalid retry policy %+v", policy)
+		}
+		o.policy = policy
+		return nil
+	}
+}
+
+func newClient(opts []Options) (client.HttpClientPort, error) {
+	mts := &CallerCfg{policy: client.DefaultRetryPolicy()}
+	for _, op := range opts {
+		err := op(mts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return client.NewHttpClientWith(mts.httpClient, mts.policy), nil
+}
+
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/95.0.4638.69 Safari/537.36"
+
+// NewClient returns a client meant to live as long as the Api and be shared
+// by every call. Headers and credentials travel in each client.Request built
+// by RequestWithToken, RequestPublic and RequestWithForm.
+func NewClient(opts ...Options) (client.HttpClientPort, error) {
+	c, err := newClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	c.DisableLogLevel()
+	return c, nil
+}
+
+func RequestWithToken(method, endpoint string, payload []byte, g *cache.Cache) (client.Request, error) {
+	req := RequestPublic(method, endpoint)
+	req.Body = payload
+
+	jraw, err := g.GetKeyVal(config.AUTHORIZE.String())
+	if err != nil {
+		return req, err
+	}
+
+	auth := models.AuthoritionToken{}
+	if err := json.Unmarshal([]byte(jraw), &auth); err != nil {
+		return req, err
+	}
+
+	req.Headers["Authorization"] = "Bearer " + auth.AccessToken
+	return req, nil
+}
+
+func RequestPublic(method, endpoint string) client.Request {
+	return client.Request{
+		Method: method,
+		URL:    endpoint,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+			"Accept":       "*/*",
+			"User-Agent":   userAgent,
+		},
+	}
+}
+
+func RequestWithForm(method, endpoint string, cfg *config.Configure) client.Request {
+	return client.Request{
+		Method: method,
+		URL:    endpoint,
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+			"Accept":       "*/*",
+			"User-Agent":   userAgent,
+		},
+		Form: url.Values{
+			"login":    {cfg.Login},
+			"password": {cfg.Password},
+		},
+	}
+}
+
+// ClientWithToken builds a new client, and so a new connection pool, on
+// every call.
+//
+// Deprecated: share the client of NewClient and send RequestWithToken.
+func ClientWithToken(method string, g *cache.Cache, opts ...Options) (client.HttpClientPort, error) {
+	c, err := newClient(opts)
+	if err != nil {
+		return nil, err
+	}
 	c.DisableLogLevel()
 	c.SetHeader(method, "Content-Type", "application/json")
 	c.SetHeader(method, "Accept", "*/*")
-	c.SetHeader(
-		method,
-		"User-Agent",
-		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/95.0.4638.69 Safari/537.36",
-	)
+	c.SetHeader(method, "User-Agent", userAgent)
 	jraw, err := g.GetKeyVal(config.AUTHORIZE.String())
 	if err != nil {
 		return c, err
@@ -33,31 +136,37 @@ func ClientWithToken(method string, g *cache.Cache) (client.HttpClientPort, erro
 	return c, nil
 }
 
-func ClientPublic(method string, g *cache.Cache) (client.HttpClientPort, error) {
-	c := client.NewHttpClient(3, 3, 3)
+// ClientPublic builds a new client, and so a new connection pool, on every
+// call.
+//
+// Deprecated: share the client of NewClient and send RequestPublic.
+func ClientPublic(method string, g *cache.Cache, opts ...Options) (client.HttpClientPort, error) {
+	c, err := newClient(opts)
+	if err != nil {
+		return nil, err
+	}
 	c.DisableLogLevel()
 	c.SetHeader(method, "Content-Type", "application/json")
 	c.SetHeader(method, "Accept", "*/*")
-	c.SetHeader(
-		method,
-		"User-Agent",
-		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/95.0.4638.69 Safari/537.36",
-	)
+	c.SetHeader(method, "User-Agent", userAgent)
 	return c, nil
 }
 
-func ClientWithForm(method string, g *cache.Cache) (client.HttpClientPort, error) {
-	c := client.NewHttpClient(3, 3, 3)
+// ClientWithForm builds a new client, and so a new connection pool, on every
+// call.
+//
+// Deprecated: share the client of NewClient and send RequestWithForm.
+func ClientWithForm(method string, cfg *config.Configure, opts ...Options) (client.HttpClientPort, error) {
+	c, err := newClient(opts)
+	if err != nil {
+		return nil, err
+	}
 	c.DisableLogLevel()
 	c.SetHeader(method, "Content-Type", "application/x-www-form-urlencoded")
 	c.SetHeader(method, "Accept", "*/*")
-	c.SetHeader(
-		method,
-		"User-Agent",
-		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/95.0.4638.69 Safari/537.36",
-	)
-
-	c.SetFormValue(method, "login", config.Config.Login)
-	c.SetFormValue(method, "password", config.Config.Password)
+	c.SetHeader(method, "User-Agent", userAgent)
+
+	c.SetFormValue(method, "login", cfg.Login)
+	c.SetFormValue(method, "password", cfg.Password)
 	return c, nil
 }
@@ -0,0 +1,134 @@
+package caller
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/thiagozs/go-cache/v1/cache/drivers/kind"
+	"github.com/thiagozs/go-cache/v1/cache/options"
+	"github.com/thiagozs/go-mbsdk/v4/config"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/cache"
+)
+
+// The benchmarks compare the clients built on every call, as the Api did
+// before, with the long-lived client of NewClient, for a public GET like
+// Tickers and an authorized POST like PlaceOrder.
+
+// benchServer answers like the tickers endpoint. With closeConns it closes
+// every connection, which a client built per call never reuses anyway, so
+// that their pools do not pile up idle sockets.
+func benchServer(b *testing.B, closeConns bool) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		if closeConns {
+			w.Header().Set("Connection", "close")
+		}
+		if r.Method == http.MethodPost && r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"pair":"BTC-BRL","last":"150000.5","buy":"150000","sell":"150001"}]`))
+	}))
+	b.Cleanup(srv.Close)
+	return srv
+}
+
+func benchCache(b *testing.B) *cache.Cache {
+	g, err := cache.NewCache(kind.GOCACHE,
+		options.OptTimeCleanUpInt(time.Minute),
+		options.OptTimeExpiration(time.Hour))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := g.SetKeyVal(config.AUTHORIZE.String(), `{"access_token":"token"}`); err != nil {
+		b.Fatal(err)
+	}
+	return g
+}
+
+func drain(b *testing.B, res *http.Response, err error) {
+	if err != nil {
+		b.Fatal(err)
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		b.Fatalf("status %d", res.StatusCode)
+	}
+}
+
+func BenchmarkTickersPerCallClient(b *testing.B) {
+	srv := benchServer(b, true)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c, err := ClientPublic(http.MethodGet, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		res, err := c.GetWithResponse(srv.URL + "/tickers?symbols=BTC-BRL")
+		drain(b, res, err)
+	}
+}
+
+func BenchmarkTickersSharedClient(b *testing.B) {
+	srv := benchServer(b, false)
+	c, err := NewClient()
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		res, err := c.Do(ctx, RequestPublic(http.MethodGet, srv.URL+"/tickers?symbols=BTC-BRL"))
+		drain(b, res, err)
+	}
+}
+
+var orderPayload = []byte(`{"async":false,"externalId":"x","limitPrice":150000,"qty":"0.001","side":"buy","type":"limit"}`)
+
+func BenchmarkPlaceOrderPerCallClient(b *testing.B) {
+	srv := benchServer(b, true)
+	g := benchCache(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c, err := ClientWithToken(http.MethodPost, g)
+		if err != nil {
+			b.Fatal(err)
+		}
+		res, err := c.PostWithResponse(srv.URL+"/orders", orderPayload)
+		drain(b, res, err)
+	}
+}
+
+func BenchmarkPlaceOrderSharedClient(b *testing.B) {
+	srv := benchServer(b, false)
+	g := benchCache(b)
+	c, err := NewClient()
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		req, err := RequestWithToken(http.MethodPost, srv.URL+"/orders", orderPayload, g)
+		if err != nil {
+			b.Fatal(err)
+		}
+		res, err := c.Do(ctx, req)
+		drain(b, res, err)
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
 	"net"
@@ -24,6 +25,15 @@ type HttpClientPort interface {
 	DeleteWithResponse(addrs string, payload []byte) (*http.Response, error)
 	PostFormWithResponse(addrs string) (*http.Response, error)
 
+	GetContext(ctx context.Context, addrs string) ([]byte, error)
+	PostContext(ctx context.Context, addrs string, body []byte) ([]byte, error)
+	DeleteContext(ctx context.Context, addrs string, payload []byte) ([]byte, error)
+
+	GetWithResponseContext(ctx context.Context, addrs string) (*http.Response, error)
+	PostWithResponseContext(ctx context.Context, addrs string, payload []byte) (*http.Response, error)
+	DeleteWithResponseContext(ctx context.Context, addrs string, payload []byte) (*http.Response, error)
+	PostFormWithResponseContext(ctx context.Context, addrs string) (*http.Response, error)
+
 	GetFreePort() (int, error)
 
 	SetHeader(method, key, value string)
@@ -41,8 +51,28 @@ type HttpClientPort interface {
 
 	DisableLogLevel()
 	EnableLogLevel()
+
+	WrapTransport(wrap func(http.RoundTripper) http.RoundTripper)
+
+	Do(ctx context.Context, r Request) (*http.Response, error)
 }
 
+// Request carries everything specific to one call, so a single HttpClient can
+// be shared by concurrent requests with different headers. Headers are added
+// to the ones set with SetHeader for the same method. Form, when set, is sent
+// url-encoded instead of Body. NoRetry sends the request only once, for calls
+// that are not idempotent.
+type Request struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    []byte
+	Form    url.Values
+	NoRetry bool
+}
+
+type noRetryCtx struct{}
+
 type HttpClient struct {
 	sync.Mutex
 	client       *retryablehttp.Client
@@ -53,26 +83,79 @@ type HttpClient struct {
 	forms        map[string]map[string]string
 }
 
+// RetryPolicy controls how many times and how long apart a request is
+// retried. Nil CheckRetry and Backoff use the retryablehttp defaults.
+type RetryPolicy struct {
+	RetryMax     int
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+	CheckRetry   retryablehttp.CheckRetry
+	Backoff      retryablehttp.Backoff
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		RetryMax:     3,
+		RetryWaitMin: 3 * time.Second,
+		RetryWaitMax: 3 * time.Second,
+	}
+}
+
 func NewHttpClient(retryWaitMinSec, retryWaitMaxSec, retryMax int) HttpClientPort {
+	return NewHttpClientWith(nil, RetryPolicy{
+		RetryMax:     retryMax,
+		RetryWaitMin: time.Duration(retryWaitMinSec) * time.Second,
+		RetryWaitMax: time.Duration(retryWaitMaxSec) * time.Second,
+	})
+}
+
+// NewHttpClientWith sends requests through hc, or a pooled client when hc is
+// nil, retrying as policy says. hc is copied, so WrapTransport never changes
+// the caller's client. When the retries run out the last response is returned
+// as is, so callers still read the error the server answered with.
+func NewHttpClientWith(hc *http.Client, policy RetryPolicy) HttpClientPort {
 	client := retryablehttp.NewClient()
-	client.RetryWaitMin = time.Duration(retryWaitMinSec) * time.Second
-	client.RetryWaitMax = time.Duration(retryWaitMaxSec) * time.Second
-	client.RetryMax = retryMax
+	if hc != nil {
+		cp := *hc
+		client.HTTPClient = &cp
+	}
+	client.RetryWaitMin = policy.RetryWaitMin
+	client.RetryWaitMax = policy.RetryWaitMax
+	client.RetryMax = policy.RetryMax
+	if policy.CheckRetry != nil {
+		client.CheckRetry = policy.CheckRetry
+	}
+	checkRetry := client.CheckRetry
+	client.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		if ctx.Value(noRetryCtx{}) != nil {
+			return false, nil
+		}
+		return checkRetry(ctx, resp, err)
+	}
+	if policy.Backoff != nil {
+		client.Backoff = policy.Backoff
+	}
+	client.ErrorHandler = retryablehttp.PassthroughErrorHandler
 	return &HttpClient{
 		client:       client,
-		MaxRetry:     retryMax,
-		RetryWaitMin: retryWaitMinSec,
-		RetryWaitMax: retryWaitMaxSec,
+		MaxRetry:     policy.RetryMax,
+		RetryWaitMin: int(policy.RetryWaitMin / time.Second),
+		RetryWaitMax: int(policy.RetryWaitMax / time.Second),
 		headers:      make(map[string]map[string]string),
 		forms:        make(map[string]map[string]string),
 	}
 }
 
 func (c *HttpClient) Get(addrs string) ([]byte, error) {
+	return c.GetContext(context.Background(), addrs)
+}
+
+func (c *HttpClient) GetContext(ctx context.Context, addrs string) ([]byte, error) {
 	req, err := retryablehttp.NewRequest(http.MethodGet, addrs, nil)
 	if err != nil {
 		return []byte{}, err
 	}
+	req = req.WithContext(ctx)
 	if len(c.headers[http.MethodGet]) > 0 {
 		for k, v := range c.headers[http.MethodGet] {
 			req.Header.Set(k, v)
@@ -88,11 +171,16 @@ func (c *HttpClient) Get(addrs string) ([]byte, error) {
 }
 
 func (c *HttpClient) Post(addrs string, payload []byte) ([]byte, error) {
+	return c.PostContext(context.Background(), addrs, payload)
+}
+
+func (c *HttpClient) PostContext(ctx context.Context, addrs string, payload []byte) ([]byte, error) {
 
 	req, err := retryablehttp.NewRequest(http.MethodPost, addrs, payload)
 	if err != nil {
 		return []byte{}, err
 	}
+	req = req.WithContext(ctx)
 	if len(c.headers[http.MethodPost]) > 0 {
 		for k, v := range c.headers[http.MethodPost] {
 			req.Header.Set(k, v)
@@ -109,11 +197,16 @@ func (c *HttpClient) Post(addrs string, payload []byte) ([]byte, error) {
 }
 
 func (c *HttpClient) Delete(addrs string, payload []byte) ([]byte, error) {
+	return c.DeleteContext(context.Background(), addrs, payload)
+}
+
+func (c *HttpClient) DeleteContext(ctx context.Context, addrs string, payload []byte) ([]byte, error) {
 
 	req, err := retryablehttp.NewRequest(http.MethodDelete, addrs, payload)
 	if err != nil {
 		return []byte{}, err
 	}
+	req = req.WithContext(ctx)
 	if len(c.headers[http.MethodDelete]) > 0 {
 		for k, v := range c.headers[http.MethodDelete] {
 			req.Header.Set(k, v)
@@ -191,6 +284,46 @@ func (c *HttpClient) EnableLogLevel() {
 	c.client.Logger = log.New(os.Stderr, "", log.LstdFlags)
 }
 
+// Do sends r reusing the underlying connection pool. It is safe for
+// concurrent use.
+func (c *HttpClient) Do(ctx context.Context, r Request) (*http.Response, error) {
+	var body interface{}
+	if r.Form != nil {
+		body = strings.NewReader(r.Form.Encode())
+	} else if r.Body != nil {
+		body = r.Body
+	}
+
+	req, err := retryablehttp.NewRequest(r.Method, r.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	if r.NoRetry {
+		ctx = context.WithValue(ctx, noRetryCtx{}, true)
+	}
+	req = req.WithContext(ctx)
+
+	c.Lock()
+	for k, v := range c.headers[strings.ToUpper(r.Method)] {
+		req.Header.Set(k, v)
+	}
+	c.Unlock()
+
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+
+	return c.client.Do(req)
+}
+
+// WrapTransport replaces the transport used for every attempt, retries
+// included, with the one returned by wrap.
+func (c *HttpClient) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	c.Lock()
+	defer c.Unlock()
+	c.client.HTTPClient.Transport = wrap(c.client.HTTPClient.Transport)
+}
+
 func (c *HttpClient) GetFreePort() (int, error) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
@@ -206,10 +339,15 @@ func (c *HttpClient) GetFreePort() (int, error) {
 }
 
 func (c *HttpClient) GetWithResponse(addrs string) (*http.Response, error) {
+	return c.GetWithResponseContext(context.Background(), addrs)
+}
+
+func (c *HttpClient) GetWithResponseContext(ctx context.Context, addrs string) (*http.Response, error) {
 	req, err := retryablehttp.NewRequest(http.MethodGet, addrs, nil)
 	if err != nil {
 		return &http.Response{}, err
 	}
+	req = req.WithContext(ctx)
 	if len(c.headers[http.MethodGet]) > 0 {
 		for k, v := range c.headers[http.MethodGet] {
 			req.Header.Set(k, v)
@@ -224,11 +362,16 @@ func (c *HttpClient) GetWithResponse(addrs string) (*http.Response, error) {
 }
 
 func (c *HttpClient) PostWithResponse(addrs string, payload []byte) (*http.Response, error) {
+	return c.PostWithResponseContext(context.Background(), addrs, payload)
+}
+
+func (c *HttpClient) PostWithResponseContext(ctx context.Context, addrs string, payload []byte) (*http.Response, error) {
 
 	req, err := retryablehttp.NewRequest(http.MethodPost, addrs, payload)
 	if err != nil {
 		return &http.Response{}, err
 	}
+	req = req.WithContext(ctx)
 	if len(c.headers[http.MethodPost]) > 0 {
 		for k, v := range c.headers[http.MethodPost] {
 			req.Header.Set(k, v)
@@ -244,11 +387,16 @@ func (c *HttpClient) PostWithResponse(addrs string, payload []byte) (*http.Respo
 }
 
 func (c *HttpClient) DeleteWithResponse(addrs string, payload []byte) (*http.Response, error) {
+	return c.DeleteWithResponseContext(context.Background(), addrs, payload)
+}
+
+func (c *HttpClient) DeleteWithResponseContext(ctx context.Context, addrs string, payload []byte) (*http.Response, error) {
 
 	req, err := retryablehttp.NewRequest(http.MethodDelete, addrs, payload)
 	if err != nil {
 		return &http.Response{}, err
 	}
+	req = req.WithContext(ctx)
 	if len(c.headers[http.MethodDelete]) > 0 {
 		for k, v := range c.headers[http.MethodDelete] {
 			req.Header.Set(k, v)
@@ -264,6 +412,10 @@ func (c *HttpClient) DeleteWithResponse(addrs string, payload []byte) (*http.Res
 }
 
 func (c *HttpClient) PostFormWithResponse(addrs string) (*http.Response, error) {
+	return c.PostFormWithResponseContext(context.Background(), addrs)
+}
+
+func (c *HttpClient) PostFormWithResponseContext(ctx context.Context, addrs string) (*http.Response, error) {
 	forms := url.Values{}
 	if len(c.forms[http.MethodPost]) > 0 {
 		for k, v := range c.forms[http.MethodPost] {
@@ -275,6 +427,7 @@ func (c *HttpClient) PostFormWithResponse(addrs string) (*http.Response, error)
 	if err != nil {
 		return &http.Response{}, err
 	}
+	req = req.WithContext(ctx)
 	if len(c.headers[http.MethodPost]) > 0 {
 		for k, v := range c.headers[http.MethodPost] {
 			req.Header.Set(k, v)
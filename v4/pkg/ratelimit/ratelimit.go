@@ -0,0 +1,271 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Group int
+
+const (
+	PUBLIC Group = iota
+	TRADING
+	WALLET
+)
+
+func (g Group) String() string {
+	return [...]string{"PUBLIC", "TRADING", "WALLET"}[g]
+}
+
+// groups maps the config.EndPoints names to their quota group. Keys not
+// listed fall back to WALLET for the WALLET_ prefix and PUBLIC otherwise.
+var groups = map[string]Group{
+	"AUTHORIZE":        TRADING,
+	"ACCOUNTS":         TRADING,
+	"BALANCE_LIST":     TRADING,
+	"POSITION_LIST":    TRADING,
+	"ORDER_GET":        TRADING,
+	"ORDER_PLACE":      TRADING,
+	"ORDER_CANCEL":     TRADING,
+	"ORDER_LIST":       TRADING,
+	"ORDER_CANCEL_ALL": TRADING,
+	"ORDERBOOK":        PUBLIC,
+	"TRADES":           PUBLIC,
+	"CANDLES":          PUBLIC,
+	"SYMBOLS":          PUBLIC,
+	"TICKERS":          PUBLIC,
+}
+
+func GroupOf(key string) Group {
+	if g, ok := groups[key]; ok {
+		return g
+	}
+	if strings.HasPrefix(key, "WALLET") {
+		return WALLET
+	}
+	return PUBLIC
+}
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst. A
+// zero Rate disables the limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// DefaultLimits are conservative assumptions, not figures taken from the
+// exchange: one request per second for public data with a burst of five,
+// three per second for trading and one per second for the wallet. Check them
+// against the rate limits section of https://api.mercadobitcoin.net/api/v4/docs
+// and set the published values with api.OptRateLimits.
+func DefaultLimits() map[Group]Limit {
+	return map[Group]Limit{
+		PUBLIC:  {Rate: 1, Burst: 5},
+		TRADING: {Rate: 3, Burst: 3},
+		WALLET:  {Rate: 1, Burst: 1},
+	}
+}
+
+type Budget struct {
+	Available    float64
+	Limit        Limit
+	BlockedUntil time.Time
+}
+
+type bucket struct {
+	limit        Limit
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b.limit.Rate <= 0 {
+		return
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.limit.Rate
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.last = now
+}
+
+// Limiter keeps one bucket per endpoint group. It is safe for concurrent use
+// and meant to be shared by every goroutine using the same credentials.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[Group]*bucket
+}
+
+func New(limits map[Group]Limit) *Limiter {
+	now := time.Now()
+	l := &Limiter{buckets: make(map[Group]*bucket)}
+	for g, limit := range limits {
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		l.buckets[g] = &bucket{limit: limit, tokens: float64(limit.Burst), last: now}
+	}
+	return l
+}
+
+// Wait blocks until the group of key has a token available or ctx is done.
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	group := GroupOf(key)
+
+	for {
+		l.mu.Lock()
+		b, ok := l.buckets[group]
+		if !ok {
+			l.mu.Unlock()
+			return nil
+		}
+
+		now := time.Now()
+		b.refill(now)
+
+		var wait time.Duration
+		switch {
+		case now.Before(b.blockedUntil):
+			wait = b.blockedUntil.Sub(now)
+		case b.limit.Rate <= 0:
+			l.mu.Unlock()
+			return nil
+		case b.tokens >= 1:
+			b.tokens--
+			l.mu.Unlock()
+			return nil
+		default:
+			wait = time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Observe blocks the group of key when the response says the quota is
+// exhausted, for the time given by Retry-After or one second without it.
+func (l *Limiter) Observe(key string, res *http.Response) {
+	if res == nil {
+		return
+	}
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return
+	}
+
+	wait, ok := RetryAfter(res)
+	if !ok {
+		if res.StatusCode != http.StatusTooManyRequests {
+			return
+		}
+		wait = time.Second
+	}
+
+	l.Block(key, wait)
+}
+
+// Block stops the group of key from sending requests for d.
+func (l *Limiter) Block(key string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[GroupOf(key)]
+	if !ok {
+		return
+	}
+
+	until := time.Now().Add(d)
+	if until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+	b.tokens = 0
+}
+
+// Budget returns the tokens currently available in each group.
+func (l *Limiter) Budget() map[Group]Budget {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	budget := make(map[Group]Budget, len(l.buckets))
+	for g, b := range l.buckets {
+		b.refill(now)
+		budget[g] = Budget{Available: b.tokens, Limit: b.limit, BlockedUntil: b.blockedUntil}
+	}
+	return budget
+}
+
+// RetryAfter parses the Retry-After header, given in seconds or as a date.
+func RetryAfter(res *http.Response) (time.Duration, bool) {
+	value := res.Header.Get("Retry-After")
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+type keyCtx struct{}
+
+// WithKey tags ctx with the config.EndPoints name of the request, used by the
+// transport to pick the bucket.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyCtx{}, key)
+}
+
+func KeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(keyCtx{}).(string)
+	return key
+}
+
+// Transport wraps base so every attempt, including retries, waits for the
+// bucket of the request key and reports throttling responses.
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, limiter: l}
+}
+
+type transport struct {
+	base    http.RoundTripper
+	limiter *Limiter
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := KeyFromContext(req.Context())
+
+	if err := t.limiter.Wait(req.Context(), key); err != nil {
+		return nil, err
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+
+	t.limiter.Observe(key, res)
+	return res, nil
+}
@@ -0,0 +1,286 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func response(status int, retryAfter string) *http.Response {
+	res := &http.Response{StatusCode: status, Header: http.Header{}}
+	if len(retryAfter) > 0 {
+		res.Header.Set("Retry-After", retryAfter)
+	}
+	return res
+}
+
+func TestGroupOf(t *testing.T) {
+	for key, want := range map[string]Group{
+		"ORDER_PLACE":     TRADING,
+		"BALANCE_LIST":    TRADING,
+		"TICKERS":         PUBLIC,
+		"WALLET_WITHDRAW": WALLET,
+		"WALLET_NEW":      WALLET,
+		"UNKNOWN":         PUBLIC,
+		"":                PUBLIC,
+	} {
+		if got := GroupOf(key); got != want {
+			t.Errorf("GroupOf(%q) is %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestBucketRefill(t *testing.T) {
+	start := time.Now()
+	b := &bucket{limit: Limit{Rate: 2, Burst: 3}, last: start}
+
+	b.refill(start.Add(500 * time.Millisecond))
+	if b.tokens != 1 {
+		t.Errorf("%v tokens after 500ms at 2/s, want 1", b.tokens)
+	}
+
+	// Never above the burst.
+	b.refill(start.Add(time.Minute))
+	if b.tokens != 3 {
+		t.Errorf("%v tokens after a minute, want the burst of 3", b.tokens)
+	}
+
+	b = &bucket{limit: Limit{Rate: 0, Burst: 3}, last: start}
+	b.refill(start.Add(time.Minute))
+	if b.tokens != 0 {
+		t.Errorf("%v tokens without a rate, want 0", b.tokens)
+	}
+}
+
+func TestWaitBurst(t *testing.T) {
+	l := New(map[Group]Limit{TRADING: {Rate: 20, Burst: 3}})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, "ORDER_PLACE"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("burst of 3 took %s", elapsed)
+	}
+
+	// The fourth one waits for a token, 50ms at 20/s.
+	start = time.Now()
+	if err := l.Wait(ctx, "ORDER_PLACE"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("fourth request went after %s, want about 50ms", elapsed)
+	}
+
+	// Groups without a bucket or without a rate do not wait.
+	start = time.Now()
+	if err := l.Wait(ctx, "TICKERS"); err != nil {
+		t.Fatal(err)
+	}
+	l = New(map[Group]Limit{TRADING: {Rate: 0}})
+	for i := 0; i < 10; i++ {
+		if err := l.Wait(ctx, "ORDER_PLACE"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("unlimited requests took %s", elapsed)
+	}
+}
+
+func TestWaitCancel(t *testing.T) {
+	l := New(map[Group]Limit{TRADING: {Rate: 0.01, Burst: 1}})
+	if err := l.Wait(context.Background(), "ORDER_PLACE"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	if err := l.Wait(ctx, "ORDER_PLACE"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("returned after %s, not when cancelled", elapsed)
+	}
+
+	// Also while the group is blocked.
+	l = New(map[Group]Limit{WALLET: {Rate: 100, Burst: 10}})
+	l.Block("WALLET_WITHDRAW", time.Minute)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "WALLET_WITHDRAW"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     *http.Response
+		blocked time.Duration
+	}{
+		{"429 with Retry-After", response(http.StatusTooManyRequests, "3"), 3 * time.Second},
+		{"429 without Retry-After", response(http.StatusTooManyRequests, ""), time.Second},
+		{"503 with Retry-After", response(http.StatusServiceUnavailable, "2"), 2 * time.Second},
+		{"503 without Retry-After", response(http.StatusServiceUnavailable, ""), 0},
+		{"200", response(http.StatusOK, "5"), 0},
+		{"no response", nil, 0},
+	}
+
+	for _, tt := range tests {
+		l := New(map[Group]Limit{TRADING: {Rate: 100, Burst: 5}, PUBLIC: {Rate: 100, Burst: 5}})
+		start := time.Now()
+		l.Observe("ORDER_PLACE", tt.res)
+
+		budget := l.Budget()
+		trading := budget[TRADING]
+		if tt.blocked == 0 {
+			if !trading.BlockedUntil.IsZero() {
+				t.Errorf("%s: blocked until %s", tt.name, trading.BlockedUntil)
+			}
+			continue
+		}
+
+		until := trading.BlockedUntil.Sub(start)
+		if until < tt.blocked || until > tt.blocked+100*time.Millisecond {
+			t.Errorf("%s: blocked for %s, want %s", tt.name, until, tt.blocked)
+		}
+		if trading.Available > 1 {
+			t.Errorf("%s: %v tokens left in a blocked group", tt.name, trading.Available)
+		}
+		if !budget[PUBLIC].BlockedUntil.IsZero() || budget[PUBLIC].Available != 5 {
+			t.Errorf("%s: other group touched: %+v", tt.name, budget[PUBLIC])
+		}
+	}
+}
+
+func TestBlockKeepsLongest(t *testing.T) {
+	l := New(map[Group]Limit{WALLET: {Rate: 1, Burst: 1}})
+
+	l.Block("WALLET_WITHDRAW", 2*time.Second)
+	first := l.Budget()[WALLET].BlockedUntil
+	l.Block("WALLET_DEPOSIT", time.Second)
+	if until := l.Budget()[WALLET].BlockedUntil; !until.Equal(first) {
+		t.Errorf("a shorter block moved the end from %s to %s", first, until)
+	}
+
+	// Groups without a bucket are ignored.
+	l.Block("ORDER_PLACE", time.Minute)
+	if _, ok := l.Budget()[TRADING]; ok {
+		t.Error("Block created a bucket")
+	}
+}
+
+func TestBlockedWait(t *testing.T) {
+	l := New(map[Group]Limit{PUBLIC: {Rate: 1000, Burst: 10}})
+	l.Block("TICKERS", 50*time.Millisecond)
+
+	start := time.Now()
+	if err := l.Wait(context.Background(), "TICKERS"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("blocked group went after %s, want about 50ms", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	d, ok := RetryAfter(response(http.StatusTooManyRequests, "120"))
+	if !ok || d != 2*time.Minute {
+		t.Errorf("seconds gave %s (%v), want 2m", d, ok)
+	}
+
+	at := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	d, ok = RetryAfter(response(http.StatusTooManyRequests, at))
+	if !ok || d <= 8*time.Second || d > 10*time.Second {
+		t.Errorf("date %s gave %s (%v), want about 10s", at, d, ok)
+	}
+
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := RetryAfter(response(http.StatusTooManyRequests, past)); !ok || d != 0 {
+		t.Errorf("past date gave %s (%v), want 0", d, ok)
+	}
+
+	for _, value := range []string{"", "-1", "soon", "1.5"} {
+		if d, ok := RetryAfter(response(http.StatusTooManyRequests, value)); ok {
+			t.Errorf("%q gave %s, want no value", value, d)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	l := New(map[Group]Limit{
+		PUBLIC:  {Rate: 0.001, Burst: 5},
+		TRADING: {Rate: 0.001, Burst: 0},
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(ctx, "TICKERS"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	budget := l.Budget()
+	if len(budget) != 2 {
+		t.Fatalf("%d groups, want 2", len(budget))
+	}
+	if public := budget[PUBLIC]; public.Available < 3 || public.Available > 3.01 || public.Limit.Burst != 5 {
+		t.Errorf("public budget %+v, want 3 of 5", public)
+	}
+	// A burst below one is raised to one.
+	if trading := budget[TRADING]; trading.Available != 1 || trading.Limit.Burst != 1 {
+		t.Errorf("trading budget %+v, want 1 of 1", trading)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	l := New(map[Group]Limit{TRADING: {Rate: 1000, Burst: 10}})
+	client := &http.Client{Transport: l.Transport(nil)}
+
+	req, err := http.NewRequestWithContext(WithKey(context.Background(), "ORDER_PLACE"), http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// The 429 blocks the group of the request key.
+	if until := l.Budget()[TRADING].BlockedUntil; time.Until(until) < 900*time.Millisecond {
+		t.Errorf("blocked until %s, want a second from now", until)
+	}
+
+	ctx, cancel := context.WithTimeout(WithKey(context.Background(), "ORDER_PLACE"), 20*time.Millisecond)
+	defer cancel()
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("%d requests reached the server, want 1", calls)
+	}
+}
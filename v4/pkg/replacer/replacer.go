@@ -14,16 +14,25 @@ import (
 type Options func(o *OptionsCfg) error
 
 type OptionsCfg struct {
+	cfg        *config.Configure
 	cache      *cache.Cache
 	log        zerolog.Logger
 	priceIn    string
 	key        string
 	symbol     string
 	orderId    string
+	accountId  string
 	params     string
 	withdrawId string
 }
 
+func OptConfig(cfg *config.Configure) Options {
+	return func(o *OptionsCfg) error {
+		o.cfg = cfg
+		return nil
+	}
+}
+
 func OptCache(cache *cache.Cache) Options {
 	return func(o *OptionsCfg) error {
 		o.cache = cache
@@ -59,6 +68,13 @@ func OptOrderId(orderId string) Options {
 	}
 }
 
+func OptAccountId(accountId string) Options {
+	return func(o *OptionsCfg) error {
+		o.accountId = accountId
+		return nil
+	}
+}
+
 func OptLog(log zerolog.Logger) Options {
 	return func(o *OptionsCfg) error {
 		o.log = log
@@ -97,13 +113,22 @@ func Endpoint(opts ...Options) (string, error) {
 	}
 
 	if strings.Contains(endpoint, "{accountId}") {
-		val, _ := mts.cache.GetKeyVal(config.ACCOUNTS.String())
-		acc := models.ListAccountsResponse{}
-		if err := json.Unmarshal([]byte(val), &acc); err != nil {
-			return "", err
+		accountId := mts.accountId
+		if len(accountId) == 0 {
+			if mts.cache == nil {
+				return "", fmt.Errorf("cache is required to resolve the account")
+			}
+			val, _ := mts.cache.GetKeyVal(config.ACCOUNTS.String())
+			acc := models.ListAccountsResponse{}
+			if err := json.Unmarshal([]byte(val), &acc); err != nil {
+				return "", err
+			}
+			if len(acc) == 0 {
+				return "", fmt.Errorf("no accounts found, login first")
+			}
+			accountId = acc[0].ID
 		}
-		fmt.Println(acc)
-		endpoint = strings.ReplaceAll(endpoint, "{accountId}", acc[0].ID)
+		endpoint = strings.ReplaceAll(endpoint, "{accountId}", accountId)
 	}
 
 	if strings.Contains(endpoint, "{symbol}") {
@@ -118,15 +143,15 @@ func Endpoint(opts ...Options) (string, error) {
 		endpoint = strings.ReplaceAll(endpoint, "{withdrawId}", mts.withdrawId)
 	}
 
-	if len(config.Config.Endpoint) > 0 {
-		endpoint = strings.ReplaceAll(endpoint, "https://api.mercadobitcoin.net", config.Config.Endpoint)
+	if mts.cfg != nil && len(mts.cfg.Endpoint) > 0 {
+		endpoint = strings.ReplaceAll(endpoint, "https://api.mercadobitcoin.net", mts.cfg.Endpoint)
 	}
 
 	if len(mts.params) > 0 {
 		endpoint = fmt.Sprintf("%s?%s", endpoint, mts.params)
 	}
 
-	if config.Config.Debug {
+	if mts.cfg != nil && mts.cfg.Debug {
 		log.Debug().
 			Str("symbol", mts.symbol).
 			Str("orderId", mts.orderId).
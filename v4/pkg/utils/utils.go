@@ -45,3 +45,11 @@ func PairQuote(value string) (pair string, quote string) {
 	quote = itens[1]
 	return
 }
+
+// ParseDecimal converts value to decimal, returning zero for an empty value.
+func ParseDecimal(value string) (decimal.Decimal, error) {
+	if len(strings.TrimSpace(value)) == 0 {
+		return decimal.Zero, nil
+	}
+	return decimal.NewFromString(strings.TrimSpace(value))
+}
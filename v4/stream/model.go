@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+type Options func(o *StreamCfg) error
+
+type StreamCfg struct {
+	endpoint     string
+	debug        bool
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	pingInterval time.Duration
+	bufferSize   int
+	dialer       *websocket.Dialer
+	onTicker     func(models.StreamTicker)
+	onTrade      func(models.StreamTrade)
+	onOrderBook  func(models.StreamOrderBook)
+	onError      func(error)
+}
+
+func OptEndpoint(endpoint string) Options {
+	return func(o *StreamCfg) error {
+		o.endpoint = endpoint
+		return nil
+	}
+}
+
+func OptDebug(on bool) Options {
+	return func(o *StreamCfg) error {
+		o.debug = on
+		return nil
+	}
+}
+
+// OptBackoff sets the first and the maximum wait between reconnections.
+func OptBackoff(min, max time.Duration) Options {
+	return func(o *StreamCfg) error {
+		o.minBackoff = min
+		o.maxBackoff = max
+		return nil
+	}
+}
+
+func OptPingInterval(interval time.Duration) Options {
+	return func(o *StreamCfg) error {
+		o.pingInterval = interval
+		return nil
+	}
+}
+
+func OptBufferSize(size int) Options {
+	return func(o *StreamCfg) error {
+		o.bufferSize = size
+		return nil
+	}
+}
+
+func OptDialer(dialer *websocket.Dialer) Options {
+	return func(o *StreamCfg) error {
+		o.dialer = dialer
+		return nil
+	}
+}
+
+// OptOnTicker delivers ticker events to fn instead of the Tickers channel.
+func OptOnTicker(fn func(models.StreamTicker)) Options {
+	return func(o *StreamCfg) error {
+		o.onTicker = fn
+		return nil
+	}
+}
+
+// OptOnTrade delivers trade events to fn instead of the Trades channel.
+func OptOnTrade(fn func(models.StreamTrade)) Options {
+	return func(o *StreamCfg) error {
+		o.onTrade = fn
+		return nil
+	}
+}
+
+// OptOnOrderBook delivers order book events to fn instead of the OrderBooks channel.
+func OptOnOrderBook(fn func(models.StreamOrderBook)) Options {
+	return func(o *StreamCfg) error {
+		o.onOrderBook = fn
+		return nil
+	}
+}
+
+// OptOnError receives connection and decoding errors. The stream keeps
+// running and reconnects on its own.
+func OptOnError(fn func(error)) Options {
+	return func(o *StreamCfg) error {
+		o.onError = fn
+		return nil
+	}
+}
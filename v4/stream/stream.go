@@ -0,0 +1,368 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/pkg/utils"
+)
+
+const DefaultEndpoint = "wss://ws.mercadobitcoin.net/ws"
+
+type Channel string
+
+const (
+	TICKER    Channel = "ticker"
+	TRADE     Channel = "trade"
+	ORDERBOOK Channel = "orderbook"
+)
+
+type Stream struct {
+	cfg *StreamCfg
+	log zerolog.Logger
+
+	mu   sync.Mutex
+	subs map[string]models.StreamSubscription
+	ids  map[string]string
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	tickers    chan models.StreamTicker
+	trades     chan models.StreamTrade
+	orderbooks chan models.StreamOrderBook
+}
+
+func New(opts ...Options) (*Stream, error) {
+	mts := &StreamCfg{
+		endpoint:     DefaultEndpoint,
+		minBackoff:   time.Second,
+		maxBackoff:   30 * time.Second,
+		pingInterval: 20 * time.Second,
+		bufferSize:   256,
+		dialer:       websocket.DefaultDialer,
+	}
+	for _, op := range opts {
+		err := op(mts)
+		if err != nil {
+			return &Stream{}, err
+		}
+	}
+
+	if mts.minBackoff <= 0 || mts.maxBackoff < mts.minBackoff {
+		return &Stream{}, fmt.Errorf("invalid backoff %s - %s", mts.minBackoff, mts.maxBackoff)
+	}
+
+	if mts.pingInterval <= 0 {
+		return &Stream{}, fmt.Errorf("invalid ping interval %s", mts.pingInterval)
+	}
+
+	if mts.bufferSize < 0 {
+		return &Stream{}, fmt.Errorf("invalid buffer size %d", mts.bufferSize)
+	}
+
+	log := zerolog.New(os.Stderr).Level(zerolog.InfoLevel).With().
+		Caller().
+		Timestamp().Logger()
+
+	return &Stream{
+		cfg:        mts,
+		log:        log,
+		subs:       make(map[string]models.StreamSubscription),
+		ids:        make(map[string]string),
+		tickers:    make(chan models.StreamTicker, mts.bufferSize),
+		trades:     make(chan models.StreamTrade, mts.bufferSize),
+		orderbooks: make(chan models.StreamOrderBook, mts.bufferSize),
+	}, nil
+}
+
+// Tickers delivers ticker events when no ticker callback is registered.
+// The channel is closed when Run returns.
+func (s *Stream) Tickers() <-chan models.StreamTicker {
+	return s.tickers
+}
+
+// Trades delivers trade events when no trade callback is registered.
+// The channel is closed when Run returns.
+func (s *Stream) Trades() <-chan models.StreamTrade {
+	return s.trades
+}
+
+// OrderBooks delivers order book events when no order book callback is
+// registered. The channel is closed when Run returns.
+func (s *Stream) OrderBooks() <-chan models.StreamOrderBook {
+	return s.orderbooks
+}
+
+func (s *Stream) SubscribeTicker(symbol string) error {
+	return s.subscribe(TICKER, symbol, 0)
+}
+
+func (s *Stream) SubscribeTrades(symbol string) error {
+	return s.subscribe(TRADE, symbol, 0)
+}
+
+// SubscribeOrderBook subscribes to the order book of symbol, limited to depth
+// levels per side when depth is greater than zero.
+func (s *Stream) SubscribeOrderBook(symbol string, depth int) error {
+	return s.subscribe(ORDERBOOK, symbol, depth)
+}
+
+func (s *Stream) Unsubscribe(channel Channel, symbol string) error {
+	id, err := StreamID(symbol)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	sub, ok := s.subs[subKey(channel, id)]
+	delete(s.subs, subKey(channel, id))
+	conn := s.conn
+	s.mu.Unlock()
+
+	if !ok || conn == nil {
+		return nil
+	}
+
+	return s.write(conn, models.StreamRequest{Type: "unsubscribe", Subscription: &sub})
+}
+
+func (s *Stream) subscribe(channel Channel, symbol string, limit int) error {
+	id, err := StreamID(symbol)
+	if err != nil {
+		return err
+	}
+
+	sub := models.StreamSubscription{Name: string(channel), ID: id, Limit: limit}
+
+	s.mu.Lock()
+	s.subs[subKey(channel, id)] = sub
+	s.ids[id] = strings.ToUpper(symbol)
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	return s.write(conn, models.StreamRequest{Type: "subscribe", Subscription: &sub})
+}
+
+// Run connects to the feed and dispatches events until ctx is done. The
+// connection is reestablished with exponential backoff and every subscription
+// is sent again after reconnecting. Run must be called only once.
+func (s *Stream) Run(ctx context.Context) error {
+	defer func() {
+		close(s.tickers)
+		close(s.trades)
+		close(s.orderbooks)
+	}()
+
+	backoff := s.cfg.minBackoff
+	for {
+		connected, err := s.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if connected {
+			backoff = s.cfg.minBackoff
+		}
+
+		s.reportError(err)
+		if s.cfg.debug {
+			s.log.Debug().Err(err).Dur("backoff", backoff).Msg("reconnecting")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.cfg.maxBackoff {
+			backoff = s.cfg.maxBackoff
+		}
+	}
+}
+
+func (s *Stream) session(ctx context.Context) (bool, error) {
+	conn, _, err := s.cfg.dialer.DialContext(ctx, s.cfg.endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	s.conn = conn
+	subs := make([]models.StreamSubscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+	}()
+
+	for i := range subs {
+		if err := s.write(conn, models.StreamRequest{Type: "subscribe", Subscription: &subs[i]}); err != nil {
+			return false, err
+		}
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-ticker.C:
+				if err := s.write(conn, models.StreamRequest{Type: "ping"}); err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(3 * s.cfg.pingInterval)); err != nil {
+			return true, err
+		}
+
+		_, bts, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+
+		if err := s.dispatch(ctx, bts); err != nil {
+			s.reportError(err)
+		}
+	}
+}
+
+func (s *Stream) dispatch(ctx context.Context, bts []byte) error {
+	msg := models.StreamMessage{}
+	if err := json.Unmarshal(bts, &msg); err != nil {
+		return fmt.Errorf("json unmarshal stream message: %w", err)
+	}
+
+	if s.cfg.debug {
+		s.log.Debug().Str("body", string(bts)).Msg("")
+	}
+
+	s.mu.Lock()
+	symbol, ok := s.ids[msg.ID]
+	s.mu.Unlock()
+	if !ok {
+		symbol = msg.ID
+	}
+
+	switch Channel(msg.Type) {
+	case TICKER:
+		ticker := models.StreamTicker{}
+		if err := json.Unmarshal(msg.Data, &ticker); err != nil {
+			return fmt.Errorf("json unmarshal ticker: %w", err)
+		}
+		ticker.Symbol = symbol
+		ticker.Timestamp = msg.TS
+		if s.cfg.onTicker != nil {
+			s.cfg.onTicker(ticker)
+			return nil
+		}
+		select {
+		case s.tickers <- ticker:
+		case <-ctx.Done():
+		}
+	case TRADE:
+		trade := models.StreamTrade{}
+		if err := json.Unmarshal(msg.Data, &trade); err != nil {
+			return fmt.Errorf("json unmarshal trade: %w", err)
+		}
+		trade.Symbol = symbol
+		trade.Timestamp = msg.TS
+		if s.cfg.onTrade != nil {
+			s.cfg.onTrade(trade)
+			return nil
+		}
+		select {
+		case s.trades <- trade:
+		case <-ctx.Done():
+		}
+	case ORDERBOOK:
+		book := models.StreamOrderBook{}
+		if err := json.Unmarshal(msg.Data, &book); err != nil {
+			return fmt.Errorf("json unmarshal orderbook: %w", err)
+		}
+		book.Symbol = symbol
+		if book.Timestamp == 0 {
+			book.Timestamp = msg.TS
+		}
+		if s.cfg.onOrderBook != nil {
+			s.cfg.onOrderBook(book)
+			return nil
+		}
+		select {
+		case s.orderbooks <- book:
+		case <-ctx.Done():
+		}
+	case "error":
+		return fmt.Errorf("stream error: %s", msg.Message)
+	}
+
+	return nil
+}
+
+func (s *Stream) write(conn *websocket.Conn, req models.StreamRequest) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(s.cfg.pingInterval)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(req)
+}
+
+func (s *Stream) reportError(err error) {
+	if err == nil {
+		return
+	}
+	if s.cfg.onError != nil {
+		s.cfg.onError(err)
+	}
+	if s.cfg.debug {
+		s.log.Error().Err(err).Msg("stream")
+	}
+}
+
+// StreamID converts a REST symbol like BTC-BRL to the feed id BRLBTC.
+func StreamID(symbol string) (string, error) {
+	if !strings.Contains(symbol, "-") {
+		return "", fmt.Errorf("invalid symbol %q, expected PAIR-QUOTE", symbol)
+	}
+	pair, quote := utils.PairQuote(strings.ToUpper(symbol))
+	return quote + pair, nil
+}
+
+func subKey(channel Channel, id string) string {
+	return string(channel) + "|" + id
+}
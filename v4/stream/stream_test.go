@@ -0,0 +1,228 @@
+package stream_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+	"github.com/thiagozs/go-mbsdk/v4/stream"
+)
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// start runs s until the test ends.
+func start(t *testing.T, s *stream.Stream) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("Run returned %v", err)
+		}
+	})
+}
+
+func subscribed(srv *mockserver.StreamServer, want ...string) func() bool {
+	return func() bool {
+		subs := srv.Subscriptions()
+		if len(subs) != len(want) {
+			return false
+		}
+		for i, sub := range subs {
+			if sub.Name+"|"+sub.ID != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	srv := mockserver.NewStream()
+	defer srv.Close()
+
+	s, err := stream.New(stream.OptEndpoint(srv.URL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SubscribeTicker("btc-brl"); err != nil {
+		t.Fatal(err)
+	}
+	start(t, s)
+
+	eventually(t, "ticker subscription", subscribed(srv, "ticker|BRLBTC"))
+
+	// Subscriptions made while connected are sent right away.
+	if err := s.SubscribeOrderBook("ETH-BRL", 10); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "orderbook subscription", subscribed(srv, "orderbook|BRLETH", "ticker|BRLBTC"))
+
+	for _, sub := range srv.Subscriptions() {
+		if sub.Name == "orderbook" && sub.Limit != 10 {
+			t.Errorf("orderbook limit %d, want 10", sub.Limit)
+		}
+	}
+
+	if err := srv.Send("ticker", "BRLBTC", map[string]string{"last": "100.5"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case ticker := <-s.Tickers():
+		if ticker.Symbol != "BTC-BRL" || ticker.Last.String() != "100.5" {
+			t.Errorf("unexpected ticker %+v", ticker)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no ticker received")
+	}
+
+	if err := s.Unsubscribe(stream.TICKER, "BTC-BRL"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "unsubscribe", subscribed(srv, "orderbook|BRLETH"))
+}
+
+func TestReconnectResubscribes(t *testing.T) {
+	srv := mockserver.NewStream()
+	defer srv.Close()
+
+	books := make(chan models.StreamOrderBook, 1)
+	s, err := stream.New(
+		stream.OptEndpoint(srv.URL()),
+		stream.OptBackoff(10*time.Millisecond, 50*time.Millisecond),
+		stream.OptOnOrderBook(func(b models.StreamOrderBook) { books <- b }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SubscribeTrades("BTC-BRL"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SubscribeOrderBook("BTC-BRL", 0); err != nil {
+		t.Fatal(err)
+	}
+	start(t, s)
+
+	eventually(t, "subscriptions", subscribed(srv, "orderbook|BRLBTC", "trade|BRLBTC"))
+
+	srv.Drop()
+	eventually(t, "reconnection", func() bool { return len(srv.Dials()) == 2 })
+	eventually(t, "resubscription", subscribed(srv, "orderbook|BRLBTC", "trade|BRLBTC"))
+
+	book := map[string]interface{}{"timestamp": 7, "asks": [][]string{{"101", "1"}}, "bids": [][]string{{"99", "2"}}}
+	if err := srv.Send("orderbook", "BRLBTC", book); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case b := <-books:
+		if b.Symbol != "BTC-BRL" || b.Timestamp != 7 || len(b.Asks) != 1 || len(b.Bids) != 1 {
+			t.Errorf("unexpected order book %+v", b)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no order book received after reconnecting")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	srv := mockserver.NewStream()
+	defer srv.Close()
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	s, err := stream.New(
+		stream.OptEndpoint(srv.URL()),
+		stream.OptBackoff(30*time.Millisecond, 60*time.Millisecond),
+		stream.OptOnError(func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Refuse(5)
+	start(t, s)
+
+	eventually(t, "connection", func() bool { return srv.Connections() == 1 })
+
+	dials := srv.Dials()
+	if len(dials) != 6 {
+		t.Fatalf("got %d dials, want 6", len(dials))
+	}
+
+	// Waits double from the minimum and stop at the maximum: 30, 60, 60...
+	mins := []time.Duration{30, 60, 60, 60, 60}
+	for i, min := range mins {
+		gap := dials[i+1].Sub(dials[i])
+		if gap < min*time.Millisecond {
+			t.Errorf("wait %d is %s, want at least %dms", i, gap, min)
+		}
+		if gap > 300*time.Millisecond {
+			t.Errorf("wait %d is %s, above the maximum backoff", i, gap)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 5 {
+		t.Errorf("got %d errors, want one per refused dial", len(errs))
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	srv := mockserver.NewStream()
+	defer srv.Close()
+
+	errs := make(chan error, 1)
+	s, err := stream.New(
+		stream.OptEndpoint(srv.URL()),
+		stream.OptOnError(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start(t, s)
+
+	eventually(t, "connection", func() bool { return srv.Connections() == 1 })
+	if err := srv.SendError("invalid subscription"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "invalid subscription") {
+			t.Errorf("unexpected error %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("error not reported")
+	}
+}
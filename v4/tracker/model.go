@@ -0,0 +1,136 @@
+package tracker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// State is the lifecycle stage of a tracked order.
+type State int
+
+const (
+	// Pending orders were not seen in the order list yet, as happens right
+	// after an async placement.
+	Pending State = iota
+	Created
+	Working
+	PartiallyFilled
+	Filled
+	Cancelled
+	Rejected
+	// Unknown orders have a status this package does not know. They are kept
+	// open, so the tracker polls them until they reach a known state.
+	Unknown
+)
+
+func (s State) String() string {
+	return [...]string{"pending", "created", "working", "partially_filled", "filled", "cancelled", "rejected", "unknown"}[s]
+}
+
+// Terminal reports whether the order can no longer change.
+func (s State) Terminal() bool {
+	return s == Filled || s == Cancelled || s == Rejected
+}
+
+// StateOf maps the status reported by the exchange to a State. Statuses it
+// does not know give Unknown.
+func StateOf(order models.GetOrderResponse) State {
+	switch order.Status {
+	case "created":
+		return Created
+	case "working":
+		if order.FilledQty.IsPositive() {
+			return PartiallyFilled
+		}
+		return Working
+	case "filled":
+		return Filled
+	case "cancelled":
+		return Cancelled
+	case "rejected":
+		return Rejected
+	}
+	return Unknown
+}
+
+// Event is emitted when a tracked order changes state or gets new
+// executions, in which case From and To are equal.
+type Event struct {
+	Symbol     string
+	OrderID    string
+	From       State
+	To         State
+	Order      models.GetOrderResponse
+	Executions []models.OrderExecution
+}
+
+// WithdrawEvent is emitted when a watched withdrawal changes status or gets
+// its transaction hash.
+type WithdrawEvent struct {
+	Symbol     string
+	ID         string
+	From       models.WithdrawStatus
+	To         models.WithdrawStatus
+	Tx         string
+	Withdrawal models.WalletWithdrawCoinResponse
+}
+
+type Options func(o *TrackerCfg) error
+
+type TrackerCfg struct {
+	interval   time.Duration
+	lookback   time.Duration
+	onEvent    func(Event)
+	onWithdraw func(WithdrawEvent)
+	onError    func(error)
+}
+
+// OptInterval sets the time between two polls, two seconds by default.
+func OptInterval(interval time.Duration) Options {
+	return func(o *TrackerCfg) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid interval %s", interval)
+		}
+		o.interval = interval
+		return nil
+	}
+}
+
+// OptLookback sets how long before Track the order list is searched, to
+// cover clock drift with the exchange. One minute by default.
+func OptLookback(lookback time.Duration) Options {
+	return func(o *TrackerCfg) error {
+		if lookback < 0 {
+			return fmt.Errorf("invalid lookback %s", lookback)
+		}
+		o.lookback = lookback
+		return nil
+	}
+}
+
+// OptOnEvent is called for every event, from the polling goroutine.
+func OptOnEvent(fn func(Event)) Options {
+	return func(o *TrackerCfg) error {
+		o.onEvent = fn
+		return nil
+	}
+}
+
+// OptOnWithdrawEvent is called for every event of a WithdrawWatcher, from
+// the polling goroutine.
+func OptOnWithdrawEvent(fn func(WithdrawEvent)) Options {
+	return func(o *TrackerCfg) error {
+		o.onWithdraw = fn
+		return nil
+	}
+}
+
+// OptOnError receives the polling errors of Run, which keeps going.
+func OptOnError(fn func(error)) Options {
+	return func(o *TrackerCfg) error {
+		o.onError = fn
+		return nil
+	}
+}
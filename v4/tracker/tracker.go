@@ -0,0 +1,297 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/api"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// ErrTimeout is returned by Wait when the order or withdrawal is not done in
+// time.
+var ErrTimeout = errors.New("timeout waiting for a terminal state")
+
+// ErrNotTracked is returned for orders and withdrawals not being followed.
+var ErrNotTracked = errors.New("not tracked")
+
+// ListFunc lists the orders of symbol created after since, usually from the
+// REST API.
+type ListFunc func(ctx context.Context, symbol string, since time.Time) (models.ListOrderResponse, error)
+
+// APIList returns a ListFunc backed by Api.ListOrders.
+func APIList(a *api.Api) ListFunc {
+	return func(ctx context.Context, symbol string, since time.Time) (models.ListOrderResponse, error) {
+		return a.ListOrdersWithContext(ctx, symbol, api.OrdCreatedFrom(strconv.FormatInt(since.Unix(), 10)))
+	}
+}
+
+type tracked struct {
+	symbol     string
+	id         string
+	since      time.Time
+	state      State
+	order      models.GetOrderResponse
+	executions []models.OrderExecution
+	seen       map[string]bool
+	done       chan struct{}
+}
+
+// Tracker follows submitted orders by polling the order list, emitting an
+// Event on every state transition and recording their executions. It is
+// safe for concurrent use.
+type Tracker struct {
+	cfg  *TrackerCfg
+	list ListFunc
+
+	pollMu sync.Mutex
+	mu     sync.Mutex
+	orders map[string]*tracked
+}
+
+func New(list ListFunc, opts ...Options) (*Tracker, error) {
+	mts := &TrackerCfg{interval: 2 * time.Second, lookback: time.Minute}
+	for _, op := range opts {
+		err := op(mts)
+		if err != nil {
+			return &Tracker{}, err
+		}
+	}
+
+	if list == nil {
+		return &Tracker{}, fmt.Errorf("list function is required")
+	}
+
+	return &Tracker{
+		cfg:    mts,
+		list:   list,
+		orders: make(map[string]*tracked),
+	}, nil
+}
+
+func key(symbol, id string) string {
+	return strings.ToUpper(symbol) + "|" + id
+}
+
+// Track starts following an order placed just now.
+func (t *Tracker) Track(symbol, orderID string) {
+	t.TrackSince(symbol, orderID, time.Now())
+}
+
+// TrackSince starts following an order created after since.
+func (t *Tracker) TrackSince(symbol, orderID string, since time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := key(symbol, orderID)
+	if _, ok := t.orders[k]; ok {
+		return
+	}
+
+	t.orders[k] = &tracked{
+		symbol: strings.ToUpper(symbol),
+		id:     orderID,
+		since:  since.Add(-t.cfg.lookback),
+		seen:   make(map[string]bool),
+		done:   make(chan struct{}),
+	}
+}
+
+// Untrack stops following the order.
+func (t *Tracker) Untrack(symbol, orderID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.orders, key(symbol, orderID))
+}
+
+// Order returns the last known version of a tracked order and its state.
+func (t *Tracker) Order(symbol, orderID string) (models.GetOrderResponse, State, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	o, ok := t.orders[key(symbol, orderID)]
+	if !ok {
+		return models.GetOrderResponse{}, Pending, fmt.Errorf("%w: %s %s", ErrNotTracked, symbol, orderID)
+	}
+	return o.order, o.state, nil
+}
+
+// Executions returns the executions recorded for a tracked order.
+func (t *Tracker) Executions(symbol, orderID string) ([]models.OrderExecution, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	o, ok := t.orders[key(symbol, orderID)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotTracked, symbol, orderID)
+	}
+	return append([]models.OrderExecution{}, o.executions...), nil
+}
+
+// Poll fetches the open tracked orders once, one list call per symbol, and
+// emits the resulting events.
+func (t *Tracker) Poll(ctx context.Context) error {
+	t.pollMu.Lock()
+	defer t.pollMu.Unlock()
+
+	t.mu.Lock()
+	since := map[string]time.Time{}
+	for _, o := range t.orders {
+		if o.state.Terminal() {
+			continue
+		}
+		if s, ok := since[o.symbol]; !ok || o.since.Before(s) {
+			since[o.symbol] = o.since
+		}
+	}
+	t.mu.Unlock()
+
+	var errs []string
+	for symbol, from := range since {
+		orders, err := t.list(ctx, symbol, from)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", symbol, err))
+			continue
+		}
+
+		for _, ev := range t.update(symbol, orders) {
+			if t.cfg.onEvent != nil {
+				t.cfg.onEvent(ev)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("poll orders: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (t *Tracker) update(symbol string, orders models.ListOrderResponse) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := []Event{}
+	for _, order := range orders {
+		o, ok := t.orders[key(symbol, order.ID)]
+		if !ok || o.state.Terminal() {
+			continue
+		}
+
+		fresh := []models.OrderExecution{}
+		for _, e := range order.Executions {
+			if !o.seen[e.ID] {
+				o.seen[e.ID] = true
+				fresh = append(fresh, e)
+			}
+		}
+		o.executions = append(o.executions, fresh...)
+
+		from, to := o.state, StateOf(order)
+		o.state = to
+		o.order = order
+
+		if from == to && len(fresh) == 0 {
+			continue
+		}
+
+		events = append(events, Event{
+			Symbol:     symbol,
+			OrderID:    order.ID,
+			From:       from,
+			To:         to,
+			Order:      order,
+			Executions: fresh,
+		})
+
+		if to.Terminal() {
+			close(o.done)
+		}
+	}
+	return events
+}
+
+// Run polls the tracked orders until ctx is done. Polling errors go to
+// OptOnError and do not stop it.
+func (t *Tracker) Run(ctx context.Context) error {
+	return run(ctx, t.cfg, t.Poll)
+}
+
+// Wait blocks until the order reaches a terminal state and returns it,
+// polling on its own at the tracker interval. A timeout of zero waits for
+// ctx only.
+func (t *Tracker) Wait(ctx context.Context, symbol, orderID string, timeout time.Duration) (models.GetOrderResponse, error) {
+	t.mu.Lock()
+	o, ok := t.orders[key(symbol, orderID)]
+	t.mu.Unlock()
+	if !ok {
+		return models.GetOrderResponse{}, fmt.Errorf("%w: %s %s", ErrNotTracked, symbol, orderID)
+	}
+
+	err := wait(ctx, o.done, timeout, t.cfg, t.Poll)
+
+	order, state, _ := t.Order(symbol, orderID)
+	if errors.Is(err, ErrTimeout) {
+		return order, fmt.Errorf("%w: %s %s is %s", ErrTimeout, symbol, orderID, state)
+	}
+	return order, err
+}
+
+// wait polls every interval until done is closed, ctx ends or the timeout
+// expires, which is reported as ErrTimeout.
+func wait(ctx context.Context, done <-chan struct{}, timeout time.Duration, cfg *TrackerCfg, poll func(context.Context) error) error {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+
+		if err := poll(ctx); err != nil && ctx.Err() == nil && cfg.onError != nil {
+			cfg.onError(err)
+		}
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-expired:
+			return ErrTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+// run calls poll every interval until ctx is done.
+func run(ctx context.Context, cfg *TrackerCfg, poll func(context.Context) error) error {
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := poll(ctx); err != nil && ctx.Err() == nil && cfg.onError != nil {
+			cfg.onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thiagozs/go-mbsdk/v4/api"
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+func newMockApi(t *testing.T) (*api.Api, *mockserver.Server) {
+	t.Helper()
+
+	srv, err := mockserver.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+
+	a, err := api.New(api.OptKey("key"), api.OptSecret("secret"), api.OptEndpoint(srv.URL()), api.OptRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.GetAccounts(); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BRL", "1000"); err != nil {
+		t.Fatal(err)
+	}
+	return a, srv
+}
+
+func TestStateOf(t *testing.T) {
+	tests := []struct {
+		status string
+		filled string
+		want   State
+	}{
+		{"created", "0", Created},
+		{"working", "0", Working},
+		{"working", "0.1", PartiallyFilled},
+		{"filled", "1", Filled},
+		{"cancelled", "0.1", Cancelled},
+		{"rejected", "0", Rejected},
+		{"expired", "0", Unknown},
+		{"", "0", Unknown},
+	}
+
+	for _, tt := range tests {
+		got := StateOf(models.GetOrderResponse{Status: tt.status, FilledQty: decimal.RequireFromString(tt.filled)})
+		if got != tt.want {
+			t.Errorf("StateOf(%q, filled %s) is %s, want %s", tt.status, tt.filled, got, tt.want)
+		}
+		if tt.want == Unknown && got.Terminal() {
+			t.Errorf("%s is terminal", got)
+		}
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	a, srv := newMockApi(t)
+
+	events := []Event{}
+	tr, err := New(APIList(a), OptInterval(10*time.Millisecond), OptOnEvent(func(e Event) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := a.SubmitOrder(api.PoSymbol("BTC-BRL"), api.PoKind(api.BUY), api.PoType("limit"), api.PoPrice("100"), api.PoQty("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Track("BTC-BRL", result.OrderID)
+
+	ctx := context.Background()
+
+	// Two fills between polls give a single transition with both executions.
+	for _, qty := range []string{"0.2", "0.2"} {
+		if err := srv.AddLiquidity("BTC-BRL", "sell", "100", qty); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tr.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fill that keeps the state gives an event with From equal to To.
+	if err := srv.AddLiquidity("BTC-BRL", "sell", "100", "0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing changed.
+	if err := tr.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.AddLiquidity("BTC-BRL", "sell", "100", "0.5"); err != nil {
+		t.Fatal(err)
+	}
+	order, err := tr.Wait(ctx, "BTC-BRL", result.OrderID, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "filled" {
+		t.Errorf("order is %s, want filled", order.Status)
+	}
+
+	want := []struct {
+		from, to   State
+		executions int
+	}{
+		{Pending, PartiallyFilled, 2},
+		{PartiallyFilled, PartiallyFilled, 1},
+		{PartiallyFilled, Filled, 1},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("%d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.From != w.from || e.To != w.to || len(e.Executions) != w.executions {
+			t.Errorf("event %d is %s -> %s with %d executions, want %s -> %s with %d",
+				i, e.From, e.To, len(e.Executions), w.from, w.to, w.executions)
+		}
+	}
+
+	executions, err := tr.Executions("BTC-BRL", result.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) != 4 {
+		t.Errorf("%d executions recorded, want 4", len(executions))
+	}
+
+	// Terminal orders are no longer listed.
+	calls := srv.Calls("ORDER_LIST")
+	if err := tr.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Calls("ORDER_LIST") != calls {
+		t.Error("listed the orders of a filled order")
+	}
+}
+
+func TestUnknownStatusKeepsPolling(t *testing.T) {
+	polls := 0
+	list := func(ctx context.Context, symbol string, since time.Time) (models.ListOrderResponse, error) {
+		polls++
+		status := "expired"
+		if polls > 2 {
+			status = "cancelled"
+		}
+		return models.ListOrderResponse{{ID: "1", Status: status}}, nil
+	}
+
+	tr, err := New(list, OptInterval(5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Track("BTC-BRL", "1")
+
+	if err := tr.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, state, _ := tr.Order("BTC-BRL", "1"); state != Unknown {
+		t.Fatalf("order is %s, want unknown", state)
+	}
+
+	order, err := tr.Wait(context.Background(), "BTC-BRL", "1", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "cancelled" {
+		t.Errorf("order is %s, want cancelled", order.Status)
+	}
+
+	if _, err := tr.Wait(context.Background(), "BTC-BRL", "2", 0); !errors.Is(err, ErrNotTracked) {
+		t.Errorf("expected ErrNotTracked, got %v", err)
+	}
+}
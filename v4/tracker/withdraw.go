@@ -0,0 +1,199 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/api"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+// WithdrawFunc fetches a withdrawal of symbol by id, usually from the REST
+// API.
+type WithdrawFunc func(ctx context.Context, symbol, id string) (models.WalletWithdrawCoinResponse, error)
+
+// APIWithdraw returns a WithdrawFunc backed by Api.WalletGetWithdrawCoin.
+func APIWithdraw(a *api.Api) WithdrawFunc {
+	return func(ctx context.Context, symbol, id string) (models.WalletWithdrawCoinResponse, error) {
+		return a.WalletGetWithdrawCoinWithContext(ctx, symbol, id)
+	}
+}
+
+type watched struct {
+	symbol     string
+	id         string
+	status     models.WithdrawStatus
+	withdrawal models.WalletWithdrawCoinResponse
+	done       chan struct{}
+}
+
+// WithdrawWatcher follows withdrawals by polling them one by one, emitting a
+// WithdrawEvent when their status changes or the transaction hash appears.
+// A coin withdrawal sent without a hash keeps being polled until the hash
+// shows up, in an event from WithdrawSent to WithdrawSent, or until it is
+// unwatched. It is safe for concurrent use.
+type WithdrawWatcher struct {
+	cfg *TrackerCfg
+	get WithdrawFunc
+
+	pollMu sync.Mutex
+	mu     sync.Mutex
+	items  map[string]*watched
+}
+
+func NewWithdrawWatcher(get WithdrawFunc, opts ...Options) (*WithdrawWatcher, error) {
+	mts := &TrackerCfg{interval: 10 * time.Second}
+	for _, op := range opts {
+		err := op(mts)
+		if err != nil {
+			return &WithdrawWatcher{}, err
+		}
+	}
+
+	if get == nil {
+		return &WithdrawWatcher{}, fmt.Errorf("withdraw function is required")
+	}
+
+	return &WithdrawWatcher{
+		cfg:   mts,
+		get:   get,
+		items: make(map[string]*watched),
+	}, nil
+}
+
+// Watch starts following the withdrawal id of symbol.
+func (w *WithdrawWatcher) Watch(symbol, id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	k := key(symbol, id)
+	if _, ok := w.items[k]; ok {
+		return
+	}
+
+	w.items[k] = &watched{
+		symbol: strings.ToUpper(symbol),
+		id:     id,
+		done:   make(chan struct{}),
+	}
+}
+
+// Unwatch stops following the withdrawal.
+func (w *WithdrawWatcher) Unwatch(symbol, id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.items, key(symbol, id))
+}
+
+// Withdrawal returns the last known version of a watched withdrawal and its
+// status.
+func (w *WithdrawWatcher) Withdrawal(symbol, id string) (models.WalletWithdrawCoinResponse, models.WithdrawStatus, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	item, ok := w.items[key(symbol, id)]
+	if !ok {
+		return models.WalletWithdrawCoinResponse{}, models.WithdrawUnknown, fmt.Errorf("%w: %s withdrawal %s", ErrNotTracked, symbol, id)
+	}
+	return item.withdrawal, item.status, nil
+}
+
+// Poll fetches every open watched withdrawal once and emits the resulting
+// events.
+func (w *WithdrawWatcher) Poll(ctx context.Context) error {
+	w.pollMu.Lock()
+	defer w.pollMu.Unlock()
+
+	w.mu.Lock()
+	open := []*watched{}
+	for _, item := range w.items {
+		if !item.status.Terminal() || item.awaitsTx() {
+			open = append(open, item)
+		}
+	}
+	w.mu.Unlock()
+
+	var errs []string
+	for _, item := range open {
+		withdrawal, err := w.get(ctx, item.symbol, item.id)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return err
+			}
+			errs = append(errs, fmt.Sprintf("%s %s: %v", item.symbol, item.id, err))
+			continue
+		}
+
+		ev, changed := w.update(item, withdrawal)
+		if changed && w.cfg.onWithdraw != nil {
+			w.cfg.onWithdraw(ev)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("poll withdrawals: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (w *WithdrawWatcher) update(item *watched, withdrawal models.WalletWithdrawCoinResponse) (WithdrawEvent, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	from, to := item.status, withdrawal.WithdrawStatus()
+	tx := item.withdrawal.Tx
+	item.status = to
+	item.withdrawal = withdrawal
+
+	if from == to && tx == withdrawal.Tx {
+		return WithdrawEvent{}, false
+	}
+
+	if to.Terminal() && !from.Terminal() {
+		close(item.done)
+	}
+
+	return WithdrawEvent{
+		Symbol:     item.symbol,
+		ID:         item.id,
+		From:       from,
+		To:         to,
+		Tx:         withdrawal.Tx,
+		Withdrawal: withdrawal,
+	}, true
+}
+
+// awaitsTx reports whether a sent withdrawal still misses its transaction
+// hash. BRL withdrawals never get one. Callers hold w.mu.
+func (item *watched) awaitsTx() bool {
+	return item.status == models.WithdrawSent && len(item.withdrawal.Tx) == 0 && item.symbol != "BRL"
+}
+
+// Run polls the watched withdrawals until ctx is done. Polling errors go to
+// OptOnError and do not stop it.
+func (w *WithdrawWatcher) Run(ctx context.Context) error {
+	return run(ctx, w.cfg, w.Poll)
+}
+
+// Wait blocks until the withdrawal is sent or failed and returns it, polling
+// on its own at the watcher interval. A timeout of zero waits for ctx only.
+func (w *WithdrawWatcher) Wait(ctx context.Context, symbol, id string, timeout time.Duration) (models.WalletWithdrawCoinResponse, error) {
+	w.mu.Lock()
+	item, ok := w.items[key(symbol, id)]
+	w.mu.Unlock()
+	if !ok {
+		return models.WalletWithdrawCoinResponse{}, fmt.Errorf("%w: %s withdrawal %s", ErrNotTracked, symbol, id)
+	}
+
+	err := wait(ctx, item.done, timeout, w.cfg, w.Poll)
+
+	withdrawal, status, _ := w.Withdrawal(symbol, id)
+	if errors.Is(err, ErrTimeout) {
+		return withdrawal, fmt.Errorf("%w: %s withdrawal %s is %s", ErrTimeout, symbol, id, status)
+	}
+	return withdrawal, err
+}
@@ -0,0 +1,239 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/thiagozs/go-mbsdk/v4/api"
+	"github.com/thiagozs/go-mbsdk/v4/mockserver"
+	"github.com/thiagozs/go-mbsdk/v4/models"
+)
+
+func newWatcher(t *testing.T, a *api.Api) (*WithdrawWatcher, *[]WithdrawEvent) {
+	t.Helper()
+
+	events := []WithdrawEvent{}
+	w, err := NewWithdrawWatcher(APIWithdraw(a), OptInterval(5*time.Millisecond), OptOnWithdrawEvent(func(e WithdrawEvent) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, &events
+}
+
+func withdrawBTC(t *testing.T, a *api.Api, srv *mockserver.Server) int {
+	t.Helper()
+
+	if err := srv.SetBalance(mockserver.DefaultAccountID, "BTC", "1"); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := a.WalletWithdrawCoin(api.WalletCoinSymbol("BTC"), api.WalletCoinAddr("bc1qtest"), api.WalletCoinQty("0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wd.ID
+}
+
+func assertWithdrawEvents(t *testing.T, got []WithdrawEvent, want ...models.WithdrawStatus) {
+	t.Helper()
+
+	if len(got) != len(want)/2 {
+		t.Fatalf("%d events, want %d: %+v", len(got), len(want)/2, got)
+	}
+	for i, e := range got {
+		if e.From != want[2*i] || e.To != want[2*i+1] {
+			t.Errorf("event %d is %s -> %s, want %s -> %s", i, e.From, e.To, want[2*i], want[2*i+1])
+		}
+	}
+}
+
+func TestWithdrawStatus(t *testing.T) {
+	tests := []struct {
+		status   int
+		tx       string
+		want     models.WithdrawStatus
+		terminal bool
+	}{
+		{1, "", models.WithdrawPending, false},
+		{1, "0xabc", models.WithdrawProcessing, false},
+		{2, "0xabc", models.WithdrawSent, true},
+		{2, "", models.WithdrawSent, true},
+		{3, "", models.WithdrawFailed, true},
+		{9, "", models.WithdrawUnknown, false},
+	}
+
+	for _, tt := range tests {
+		got := models.WalletWithdrawCoinResponse{Status: tt.status, Tx: tt.tx}.WithdrawStatus()
+		if got != tt.want || got.Terminal() != tt.terminal {
+			t.Errorf("status %d tx %q is %s (terminal %v), want %s (terminal %v)",
+				tt.status, tt.tx, got, got.Terminal(), tt.want, tt.terminal)
+		}
+	}
+}
+
+func TestWithdrawTransitions(t *testing.T) {
+	a, srv := newMockApi(t)
+	w, events := newWatcher(t, a)
+	id := withdrawBTC(t, a, srv)
+	w.Watch("BTC", strconv.Itoa(id))
+
+	ctx := context.Background()
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.SetWithdrawStatus(id, 1, "0xabc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing changed.
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.SetWithdrawStatus(id, 2, "0xabc"); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := w.Wait(ctx, "BTC", strconv.Itoa(id), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wd.Tx != "0xabc" {
+		t.Errorf("tx %q, want 0xabc", wd.Tx)
+	}
+
+	assertWithdrawEvents(t, *events,
+		models.WithdrawUnknown, models.WithdrawPending,
+		models.WithdrawPending, models.WithdrawProcessing,
+		models.WithdrawProcessing, models.WithdrawSent)
+
+	// Terminal withdrawals are no longer fetched.
+	calls := srv.Calls("WALLET_GETWITHDRAW")
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Calls("WALLET_GETWITHDRAW") != calls {
+		t.Error("fetched a sent withdrawal")
+	}
+}
+
+func TestWithdrawSentWithoutTx(t *testing.T) {
+	a, srv := newMockApi(t)
+	w, events := newWatcher(t, a)
+	id := withdrawBTC(t, a, srv)
+	w.Watch("BTC", strconv.Itoa(id))
+
+	if err := srv.SetWithdrawStatus(id, 2, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := w.Wait(ctx, "BTC", strconv.Itoa(id), time.Second); err != nil {
+		t.Fatalf("Wait did not return for a done withdrawal without hash: %v", err)
+	}
+
+	// The hash comes in its own event.
+	if err := srv.SetWithdrawStatus(id, 2, "0xdef"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	assertWithdrawEvents(t, *events,
+		models.WithdrawUnknown, models.WithdrawSent,
+		models.WithdrawSent, models.WithdrawSent)
+	if tx := (*events)[1].Tx; tx != "0xdef" {
+		t.Errorf("tx %q, want 0xdef", tx)
+	}
+
+	calls := srv.Calls("WALLET_GETWITHDRAW")
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Calls("WALLET_GETWITHDRAW") != calls {
+		t.Error("fetched a sent withdrawal with its hash")
+	}
+}
+
+func TestWithdrawFiatSent(t *testing.T) {
+	a, srv := newMockApi(t)
+	w, _ := newWatcher(t, a)
+	ref := srv.AddBankAccount(mockserver.DefaultAccountID)
+
+	wd, err := a.WalletWithdrawFiat(api.WalletFiatAccRef(ref), api.WalletFiatQty("100"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Watch("BRL", strconv.Itoa(wd.ID))
+
+	if err := srv.SetWithdrawStatus(wd.ID, 2, ""); err != nil {
+		t.Fatal(err)
+	}
+	got, err := w.Wait(context.Background(), "BRL", strconv.Itoa(wd.ID), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.WithdrawStatus() != models.WithdrawSent {
+		t.Errorf("withdrawal is %s, want sent", got.WithdrawStatus())
+	}
+
+	// BRL withdrawals have no hash to wait for.
+	calls := srv.Calls("WALLET_GETWITHDRAW")
+	if err := w.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Calls("WALLET_GETWITHDRAW") != calls {
+		t.Error("fetched a sent BRL withdrawal")
+	}
+}
+
+func TestWithdrawFailed(t *testing.T) {
+	a, srv := newMockApi(t)
+	w, events := newWatcher(t, a)
+	id := withdrawBTC(t, a, srv)
+	w.Watch("BTC", strconv.Itoa(id))
+
+	if err := srv.SetWithdrawStatus(id, 3, ""); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := w.Wait(context.Background(), "BTC", strconv.Itoa(id), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wd.WithdrawStatus() != models.WithdrawFailed {
+		t.Errorf("withdrawal is %s, want failed", wd.WithdrawStatus())
+	}
+	assertWithdrawEvents(t, *events, models.WithdrawUnknown, models.WithdrawFailed)
+}
+
+func TestWithdrawTimeout(t *testing.T) {
+	a, srv := newMockApi(t)
+	w, _ := newWatcher(t, a)
+	id := withdrawBTC(t, a, srv)
+	w.Watch("BTC", strconv.Itoa(id))
+
+	start := time.Now()
+	wd, err := w.Wait(context.Background(), "BTC", strconv.Itoa(id), 50*time.Millisecond)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("timed out after %s", elapsed)
+	}
+	if wd.WithdrawStatus() != models.WithdrawPending {
+		t.Errorf("withdrawal is %s, want pending", wd.WithdrawStatus())
+	}
+
+	w.Unwatch("BTC", strconv.Itoa(id))
+	if _, err := w.Wait(context.Background(), "BTC", strconv.Itoa(id), 0); !errors.Is(err, ErrNotTracked) {
+		t.Errorf("expected ErrNotTracked, got %v", err)
+	}
+}